@@ -0,0 +1,94 @@
+package action
+
+// Imports.
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/manifest"
+import "log"
+import "os"
+
+// Move every database listed in a release manifest to its target revision. The 
+// plan is worked out and shown before any database is updated. Databases are 
+// updated one at a time in dependency order, each in a separate process. If an 
+// update fails no more are started and the outcome for each database is shown 
+// so it's clear which databases have moved.
+func ApplyManifest(file string, dryRun bool, backup bool) {
+
+	database.AssertConfigDatabaseExists()
+
+	release, err := manifest.ReadFile(file)
+	if err != nil {
+		log.Println(err)
+		log.Fatalf("Manifest '%s' could not be read.\n", file)
+	}
+
+	targets, err := release.Order()
+	if err != nil {
+		log.Println(err)
+		log.Fatalf("Manifest '%s' could not be ordered.\n", file)
+	}
+
+	updates := make([]databaseUpdate, 0)
+	for _, target := range targets {
+		database.AssertDatabaseExists(target.Database)
+		database.AssertNoPendingRevision(target.Database)
+		database.AssertNoInterruptedUpdate(target.Database)
+
+		// The revision has already been validated when reading the manifest.
+		revision, _ := target.ParseRevision()
+		head        := database.GetHeadRevision(target.Database)
+		current     := database.GetCurrentSchemaRevision(target.Database)
+
+		if revision == 0 {
+			revision = head
+		}
+
+		if revision > head {
+			log.Fatalf("Database '%s' does not have a revision '%d'.\n", target.Database, revision)
+		}
+
+		update := databaseUpdate{Name: target.Database, From: current, To: revision, Outcome: UPDATE_PENDING}
+		if current == revision {
+			update.Outcome = UPDATE_NOT_NEEDED
+		}
+		updates = append(updates, update)
+	}
+
+	if release.Release != "" {
+		log.Printf("Plan for release '%s':\n", release.Release)
+	}
+	printDatabaseUpdates(updates)
+
+	if dryRun {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.Println(err)
+		log.Fatalln("Can not find the snap executable to update the databases.")
+	}
+
+	failed := ""
+	for index := range updates {
+		update := &updates[index]
+		if update.Outcome != UPDATE_PENDING {
+			continue
+		}
+		if failed != "" {
+			update.Outcome = UPDATE_SKIPPED
+			continue
+		}
+		log.Printf("Updating database '%s' from revision '%d' to '%d'.\n", update.Name, update.From, update.To)
+		update.Outcome, update.Message = runUpdateProcess(executable, update.Name, update.To, backup)
+		if update.Outcome == UPDATE_FAILED {
+			failed = update.Name
+		}
+	}
+
+	printDatabaseUpdates(updates)
+
+	if failed != "" {
+		log.Fatalf("Release stopped because database '%s' failed to update. Only the databases marked as updated have moved.\n", failed)
+	}
+	log.Println("Manifest applied successfully.")
+}
@@ -0,0 +1,61 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "os"
+import "text/tabwriter"
+
+// List the backups taken of a managed database.
+func ListBackups(databaseName string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	backups := database.GetBackups(databaseName)
+
+	if len(backups) > 0 {
+		writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+		fmt.Fprintln(writer, "Backup\tRevision\tDirection\tTables\tDate")
+		fmt.Fprintln(writer, "------\t--------\t---------\t------\t----")
+		for _, backup := range backups {
+			fmt.Fprintln(writer, backup.TabbedString())
+		}
+		writer.Flush()
+	} else {
+		log.Printf("No backups found for database '%s'.\n", databaseName)
+	}
+}
+
+// Restore the data held in a backup to a managed database. If requested the 
+// backup is removed afterwards.
+func RestoreBackup(databaseName string, backupName string, remove bool) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+	database.AssertNoPendingRevision(databaseName)
+	database.AssertNoInterruptedUpdate(databaseName)
+
+	if !database.RestoreBackup(databaseName, backupName) {
+		log.Fatalf("Database '%s' does not have a backup '%s'.\n", databaseName, backupName)
+	}
+	log.Println("Backup restored successfully.")
+
+	if remove {
+		database.RemoveBackup(databaseName, backupName)
+		log.Println("Backup removed successfully.")
+	}
+}
+
+// Remove a backup of a managed database.
+func RemoveBackup(databaseName string, backupName string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	if !database.RemoveBackup(databaseName, backupName) {
+		log.Fatalf("Database '%s' does not have a backup '%s'.\n", databaseName, backupName)
+	}
+	log.Println("Backup removed successfully.")
+}
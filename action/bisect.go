@@ -0,0 +1,103 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/config"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "os"
+import "os/exec"
+import "strings"
+
+// Find the first revision of a database that fails a check using a binary 
+// search between a good and a bad revision. At each step a temporary copy of 
+// the database is created at the revision being tested and the check is run 
+// against it. The check is either a shell command, which passes if it exits 
+// with a zero status, or an SQL assertion. Temporary copies are deleted after 
+// each check.
+func Bisect(databaseName string, revisionString string, check string, isSql bool) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	if !strings.Contains(revisionString, "..") {
+		log.Fatalf("Revisions '%s' must be specified as '<good>..<bad>'.\n", revisionString)
+	}
+	good, bad := parseRevisions(revisionString)
+	head      := database.GetHeadRevision(databaseName)
+
+	if good < 1 || good >= bad {
+		log.Fatalf("The good revision '%d' must be at least 1 and before the bad revision '%d'.\n", good, bad)
+	}
+	if bad > head {
+		log.Fatalf("Database '%s' does not have a revision '%d'.\n", databaseName, bad)
+	}
+
+	if runBisectCheck(databaseName, good, check, isSql) {
+		log.Printf("Revision '%d' is good.\n", good)
+	} else {
+		log.Fatalf("Revision '%d' was given as good but fails the check.\n", good)
+	}
+	if runBisectCheck(databaseName, bad, check, isSql) {
+		log.Fatalf("Revision '%d' was given as bad but passes the check.\n", bad)
+	} else {
+		log.Printf("Revision '%d' is bad.\n", bad)
+	}
+
+	for bad - good > 1 {
+		log.Printf("Bisecting: %d revision(s) left to test.\n", bad - good - 1)
+		middle := good + (bad - good) / 2
+		if runBisectCheck(databaseName, middle, check, isSql) {
+			log.Printf("Revision '%d' is good.\n", middle)
+			good = middle
+		} else {
+			log.Printf("Revision '%d' is bad.\n", middle)
+			bad = middle
+		}
+	}
+
+	fmt.Printf("Revision %d is the first bad revision.\n", bad)
+	for _, entry := range database.GetLogEntries(databaseName, database.LogFilter{From: bad, To: bad}) {
+		fmt.Printf("Author: %s\n", entry.Author)
+		fmt.Printf("Date: %s\n", entry.Date)
+		fmt.Println("")
+		fmt.Printf("    %s\n", entry.Comment)
+	}
+}
+
+// Run the check against a temporary copy of a database at a revision. Returns 
+// true if the check passes. The copy is deleted afterwards.
+func runBisectCheck(databaseName string, revision uint64, check string, isSql bool) (bool) {
+	temp := database.CreateTempCopy(databaseName, revision)
+	defer database.DeleteTempDatabases()
+
+	if isSql {
+		passed, err := database.RunSqlAssertion(temp, check)
+		if err != nil {
+			log.Printf("Error occurred running the SQL assertion at revision '%d': %s\n", revision, err)
+		}
+		return passed
+	}
+
+	server  := config.GetConfig().Database
+	command := exec.Command("sh", "-c", check)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Env    = append(os.Environ(),
+		"SNAP_DSN=" + server.DnsString() + temp,
+		"SNAP_DATABASE=" + temp,
+		"SNAP_REVISION=" + formatUint(revision),
+		"SNAP_HOST=" + server.Host,
+		"SNAP_PORT=" + server.Port,
+		"SNAP_USER=" + server.User,
+		"SNAP_PASSWORD=" + server.Password,
+	)
+
+	err := command.Run()
+	if _, failed := err.(*exec.ExitError); err != nil && !failed {
+		database.DeleteTempDatabases()
+		log.Println(err)
+		log.Fatalf("Can not run the check '%s'.\n", check)
+	}
+	return err == nil
+}
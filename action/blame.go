@@ -0,0 +1,109 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
+import "github.com/nomad-software/snap/sanitise"
+import "os"
+import "text/tabwriter"
+
+// A line of a table definition attributed to the revision that last changed it.
+type blameLine struct {
+	Revision uint64 `json:"revision" yaml:"revision"`
+	Author string `json:"author" yaml:"author"`
+	Date string `json:"date" yaml:"date"`
+	Line string `json:"line" yaml:"line"`
+}
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this blameLine) TabbedString() (string) {
+	return fmt.Sprintf("%d\t%s\t%s\t%s", this.Revision, this.Author, this.Date, this.Line)
+}
+
+// The blame of a table in machine readable output.
+type blameDocument struct {
+	Database string `json:"database" yaml:"database"`
+	Table string `json:"table" yaml:"table"`
+	Revision uint64 `json:"revision" yaml:"revision"`
+	Lines []blameLine `json:"lines" yaml:"lines"`
+}
+
+// Document interface implementation.
+func (this blameDocument) Kind() (string) {
+	return "blame"
+}
+
+// Document interface implementation.
+func (this blameDocument) CsvHeader() ([]string) {
+	return []string{"database", "table", "revision", "author", "date", "line"}
+}
+
+// Document interface implementation.
+func (this blameDocument) CsvRows() (rows [][]string) {
+	for _, line := range this.Lines {
+		rows = append(rows, []string{this.Database, this.Table, formatUint(line.Revision), line.Author, line.Date, line.Line})
+	}
+	return
+}
+
+// Show the revision that last changed each line of a table's definition. The 
+// schema snapshot of every revision up to the passed one is compared with the 
+// one before it. A line is attributed to the revision it first appeared in and 
+// keeps that attribution for as long as it remains unchanged. If the table is 
+// dropped and created again all of its lines are attributed afresh.
+func ShowBlame(databaseName string, table string, revision uint64, format string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	head := database.GetHeadRevision(databaseName)
+
+	if revision > head {
+		output.Fail(format, fmt.Errorf("Database '%s' does not have a revision '%d'.", databaseName, revision))
+	}
+
+	if revision <= 0 {
+		revision = head
+	}
+
+	attributed := make(map[string]blameLine)
+	lines      := make([]blameLine, 0)
+
+	for _, snapshot := range database.GetSchemaHistory(databaseName) {
+		if snapshot.Revision > revision {
+			break
+		}
+		definition, found := sanitise.TableDefinition(snapshot.FullSql, table)
+		current           := make(map[string]blameLine)
+		lines              = make([]blameLine, 0)
+		if found {
+			for _, text := range definition {
+				line, ok := attributed[text]
+				if !ok {
+					line = blameLine{snapshot.Revision, snapshot.Author, snapshot.Date, text}
+				}
+				current[text] = line
+				lines         = append(lines, line)
+			}
+		}
+		attributed = current
+	}
+
+	if len(lines) == 0 {
+		output.Fail(format, fmt.Errorf("Table '%s' does not exist in database '%s' at revision '%d'.", table, databaseName, revision))
+	}
+
+	if !output.IsText(format) {
+		output.Write(format, blameDocument{databaseName, table, revision, lines})
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+	fmt.Fprintln(writer, "Revision\tAuthor\tDate\tDefinition")
+	fmt.Fprintln(writer, "--------\t------\t----\t----------")
+	for _, line := range lines {
+		fmt.Fprintln(writer, line.TabbedString())
+	}
+	writer.Flush()
+}
@@ -1,17 +1,25 @@
 package action
 
 // Imports.
+import "fmt"
 import "github.com/nomad-software/snap/config"
 import "github.com/nomad-software/snap/database"
 import "github.com/nomad-software/snap/sanitise"
 import "log"
+import "os"
 import "strings"
+import "text/tabwriter"
 
-// Commit a new file containing schema updates to a managed database.
-func CommitFile(databaseName string, file string, comment string) {
+// Commit a new file containing schema updates to a managed database. If sample 
+// rows or a fixture file are passed, the file is validated against data. If 
+// backup is true any tables with data that could be lost are backed up first.
+func CommitFile(databaseName string, file string, comment string, allowDestructive bool, sampleRows uint64, fixture string, backup bool) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
+	database.AssertHasOwnHistory(databaseName)
+	database.AssertNoPendingRevision(databaseName)
+	database.AssertNoInterruptedUpdate(databaseName)
 
 	head    := database.GetHeadRevision(databaseName)
 	current := database.GetCurrentSchemaRevision(databaseName)
@@ -21,7 +29,28 @@ func CommitFile(databaseName string, file string, comment string) {
 
 	validateSqlFileFormat(file)
 
-	database.ValidateSchemaUpdate(databaseName, file)
+	if !lintFile(file, databaseName, allowDestructive) {
+		log.Fatalf("File '%s' not committed because it contains lint errors.", file)
+	}
+
+	seed   := database.SeedOptions{SampleRows: sampleRows, FixtureFile: fixture}
+	counts := database.ValidateSchemaUpdate(databaseName, file, seed)
+	if seed.Enabled() {
+		writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+		fmt.Fprintln(writer, "Table\tSeeded\tUp\tDown\tUp again")
+		fmt.Fprintln(writer, "-----\t------\t--\t----\t--------")
+		for _, entry := range counts {
+			fmt.Fprintln(writer, entry.TabbedString())
+		}
+		writer.Flush()
+		for _, entry := range counts {
+			for _, warning := range entry.Warnings() {
+				log.Printf("Warning: %s\n", warning)
+			}
+		}
+	}
+
+	database.SetBackupsEnabled(backup)
 	database.CreateNewRevision(databaseName, file, comment)
 
 	log.Println("File committed successfully.")
@@ -0,0 +1,112 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/sanitise"
+import "log"
+import "os"
+import "strconv"
+import "strings"
+
+// One side of a schema comparison.
+type schemaSide struct {
+	Ref string
+	Database string
+	Sql string
+}
+
+// Show an SQL diff between the schemas of two references. Each reference can 
+// be a dump file, a live database on the default or a named server, or a 
+// managed database at a revision. If the databases have different names, 
+// references to the second are renamed to the first so only real differences 
+// are shown.
+func Compare(fromRef string, toRef string) {
+
+	database.AssertConfigDatabaseExists()
+
+	from := loadSchemaRef(fromRef)
+	to   := loadSchemaRef(toRef)
+
+	if from.Database != "" && to.Database != "" && from.Database != to.Database {
+		to.Sql = strings.Replace(to.Sql, fmt.Sprintf("`%s`", to.Database), fmt.Sprintf("`%s`", from.Database), -1)
+	}
+
+	output, err := diffSchemas(from.Ref, from.Sql, to.Ref, to.Sql)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if output == "" {
+		log.Println("Schemas are identical.")
+		return
+	}
+	fmt.Println(output)
+}
+
+// Load the normalised schema of a reference. If a file exists with the name of 
+// the reference it is read as a dump file. Otherwise the reference is in the 
+// format '[server:]database' for a live database or 'database@revision' for a 
+// managed database at a revision, where the revision can be 'head'.
+func loadSchemaRef(ref string) (side schemaSide) {
+	side.Ref = ref
+
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		sql          := sanitise.ReadFile(ref)
+		side.Database = getDumpDatabaseName(sql)
+		side.Sql      = database.NormaliseSchema(side.Database, sql)
+		return
+	}
+
+	server   := ""
+	revision := ""
+	name     := ref
+	if index := strings.Index(name, ":"); index >= 0 {
+		server = name[:index]
+		name   = name[index + 1:]
+	}
+	if index := strings.LastIndex(name, "@"); index >= 0 {
+		revision = name[index + 1:]
+		name     = name[:index]
+	}
+	side.Database = name
+
+	switch {
+		case server != "" && revision != "":
+			log.Fatalf("Reference '%s' can not specify both a server and a revision.\n", ref)
+
+		case server != "":
+			side.Sql = database.GenerateServerSchema(server, name)
+
+		case revision != "":
+			database.AssertDatabaseExists(name)
+			number := database.GetHeadRevision(name)
+			if revision != "head" {
+				parsed, err := strconv.ParseUint(revision, 10, 64)
+				if err != nil || parsed == 0 || parsed > number {
+					log.Fatalf("Database '%s' does not have a revision '%s'.\n", name, revision)
+				}
+				number = parsed
+			}
+			side.Sql = database.NormaliseSchema(name, database.GetSchema(name, number))
+
+		default:
+			database.AssertDatabaseExists(name)
+			side.Sql = database.GenerateSchema(name)
+	}
+	return
+}
+
+// Return the name of the database created by a dump file, or an empty string 
+// if it doesn't create one.
+func getDumpDatabaseName(sql string) (string) {
+	for _, statement := range sanitise.SplitStatements(sql) {
+		if !statement.IsCreateDatabase() {
+			continue
+		}
+		if name := statement.ObjectName("DATABASE"); name != "" {
+			return name
+		}
+		return statement.ObjectName("SCHEMA")
+	}
+	return ""
+}
@@ -3,14 +3,39 @@ package action
 // Imports.
 import "fmt"
 import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
 import "io/ioutil"
 import "log"
+import "os"
 import "os/exec"
 import "strconv"
 import "strings"
 
-// Copy a full database to a destination at a particular revision.
-func Diff(databaseName string, revisionString string) {
+// A diff between two revisions in machine readable output.
+type diffDocument struct {
+	Database string `json:"database" yaml:"database"`
+	FromRevision uint64 `json:"fromRevision" yaml:"fromRevision"`
+	ToRevision uint64 `json:"toRevision" yaml:"toRevision"`
+	Diff string `json:"diff" yaml:"diff"`
+}
+
+// Document interface implementation.
+func (this diffDocument) Kind() (string) {
+	return "diff"
+}
+
+// Document interface implementation.
+func (this diffDocument) CsvHeader() ([]string) {
+	return []string{"database", "fromRevision", "toRevision", "diff"}
+}
+
+// Document interface implementation.
+func (this diffDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Database, formatUint(this.FromRevision), formatUint(this.ToRevision), this.Diff}}
+}
+
+// Show a diff between two revisions of a managed database.
+func Diff(databaseName string, revisionString string, format string) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
@@ -20,62 +45,106 @@ func Diff(databaseName string, revisionString string) {
 		to = database.GetHeadRevision(databaseName)
 	}
 	if from > to {
-		log.Fatalln("'From' revision cannot be greater than to revision.")
+		output.Fail(format, fmt.Errorf("'From' revision cannot be greater than to revision."))
 	}
 
-	fromFile := fmt.Sprintf("/tmp/revision-%d", from)
-	toFile   := fmt.Sprintf("/tmp/revision-%d", to)
+	document, err := newDiffDocument(databaseName, from, to)
+	if err != nil {
+		output.Fail(format, err)
+	}
+
+	if !output.IsText(format) {
+		output.Write(format, document)
+		return
+	}
+
+	fmt.Println(document.Diff)
+}
+
+// Create a diff between two revisions of a managed database.
+func newDiffDocument(databaseName string, from uint64, to uint64) (diffDocument, error) {
+	fromSql := database.GetSchema(databaseName, from)
+	toSql   := database.GetSchema(databaseName, to)
 
-	fromSql  := database.GetSchema(databaseName, from)
-	toSql    := database.GetSchema(databaseName, to)
+	diff, err := diffSchemas(fmt.Sprintf("revision-%d", from), fromSql, fmt.Sprintf("revision-%d", to), toSql)
+	return diffDocument{databaseName, from, to, diff}, err
+}
 
-	writeFile(fromFile, fromSql)
-	writeFile(toFile, toSql)
+// Return a unified diff between two schemas. Each schema is written to a 
+// temporary file for the diff command, which are labelled with the passed 
+// names in the diff and removed afterwards.
+func diffSchemas(fromName string, fromSql string, toName string, toSql string) (string, error) {
+	fromFile, err := writeTempFile("snap-diff-", fromSql)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(fromFile)
 
-	output, err := exec.Command("diff", "-u", fromFile, toFile).CombinedOutput()
+	toFile, err := writeTempFile("snap-diff-", toSql)
 	if err != nil {
-		switch err.(type) {
-			case *exec.ExitError:
-				// Diff returns an exit code of 1 if the files are different. 
-				// So lets just skip that case.
-			default:
-				log.Fatalln(err)
-		}
+		return "", err
+	}
+	defer os.Remove(toFile)
+
+	output, err := exec.Command("diff", "-u", "--label", fromName, "--label", toName, fromFile, toFile).CombinedOutput()
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		// Diff returns an exit code of 1 if the files are different. So lets 
+		// just skip that case.
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("Error occurred running diff: %s %s", err, strings.TrimSpace(string(output)))
 	}
 
-	fmt.Println(string(output))
+	return string(output), nil
 }
 
 // Parse the revisions from the revision string.
 func parseRevisions(revisionString string) (from uint64, to uint64) {
-	var err error
+	from, to, err := splitRevisions(revisionString)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return
+}
+
+// Split a revision string in the format '<from>[..<to>]' into its revisions. 
+// The to revision is zero if it's not specified.
+func splitRevisions(revisionString string) (from uint64, to uint64, err error) {
 	if strings.Contains(revisionString, "..") {
 		revisions := strings.Split(revisionString, "..")
 		if len(revisions) == 2 {
 			from, err = strconv.ParseUint(revisions[0], 10, 64)
 			if err != nil {
-				log.Fatalf("'From' revision can not be recognised in '%s'.\n", revisionString)
+				return 0, 0, fmt.Errorf("'From' revision can not be recognised in '%s'.", revisionString)
 			}
 			to, err = strconv.ParseUint(revisions[1], 10, 64)
 			if err != nil {
-				log.Fatalf("'To' revision can not be recognised in '%s'.\n", revisionString)
+				return 0, 0, fmt.Errorf("'To' revision can not be recognised in '%s'.", revisionString)
 			}
 		} else {
-			log.Fatalf("Revisions '%s' are not specified correctly.\n", revisionString)
+			return 0, 0, fmt.Errorf("Revisions '%s' are not specified correctly.", revisionString)
 		}
 	} else {
 		from, err = strconv.ParseUint(revisionString, 10, 64)
 		if err != nil {
-			log.Fatalf("Revision '%s' is not specified correctly.\n", revisionString)
+			return 0, 0, fmt.Errorf("Revision '%s' is not specified correctly.", revisionString)
 		}
 	}
 	return
 }
 
-// Write text to a file.
-func writeFile(file string, text string) {
-	err := ioutil.WriteFile(file, []byte(text), 0644)
+// Write text to a new temporary file, returning its name.
+func writeTempFile(prefix string, text string) (string, error) {
+	file, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", fmt.Errorf("Error creating a temporary file: %s", err)
+	}
+	_, err = file.WriteString(text)
+	file.Close()
 	if err != nil {
-		log.Fatalln("Error writing to temporary file '%s'", file)
+		os.Remove(file.Name())
+		return "", fmt.Errorf("Error writing to temporary file '%s'.", file.Name())
 	}
+	return file.Name(), nil
 }
@@ -3,10 +3,32 @@ package action
 // Imports.
 import "fmt"
 import "github.com/nomad-software/snap/database"
-import "log"
+import "github.com/nomad-software/snap/output"
+
+// A revision's full schema in machine readable output.
+type schemaDocument struct {
+	Database string `json:"database" yaml:"database"`
+	Revision uint64 `json:"revision" yaml:"revision"`
+	FullSql string `json:"fullSql" yaml:"fullSql"`
+}
+
+// Document interface implementation.
+func (this schemaDocument) Kind() (string) {
+	return "schema"
+}
+
+// Document interface implementation.
+func (this schemaDocument) CsvHeader() ([]string) {
+	return []string{"database", "revision", "fullSql"}
+}
+
+// Document interface implementation.
+func (this schemaDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Database, formatUint(this.Revision), this.FullSql}}
+}
 
 // Show a managed database's full SQL at a particular revision.
-func ShowFullSql(databaseName string, revision uint64) {
+func ShowFullSql(databaseName string, revision uint64, format string) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
@@ -14,7 +36,7 @@ func ShowFullSql(databaseName string, revision uint64) {
 	head := database.GetHeadRevision(databaseName)
 
 	if revision > head {
-		log.Fatalf("Database '%s' does not have a revision '%d'.\n", databaseName, revision)
+		output.Fail(format, fmt.Errorf("Database '%s' does not have a revision '%d'.", databaseName, revision))
 	}
 
 	if revision <= 0 {
@@ -22,5 +44,11 @@ func ShowFullSql(databaseName string, revision uint64) {
 	}
 
 	fullSql := database.GetSchema(databaseName, revision)
+
+	if !output.IsText(format) {
+		output.Write(format, schemaDocument{databaseName, revision, fullSql})
+		return
+	}
+
 	fmt.Println(fullSql)
 }
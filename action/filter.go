@@ -0,0 +1,52 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "os"
+import "text/tabwriter"
+
+// List the object filters of a database.
+func ListObjectFilters(databaseName string) {
+
+	database.AssertConfigDatabaseExists()
+
+	filters := database.GetObjectFilters(databaseName)
+
+	if len(filters) > 0 {
+		writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+		fmt.Fprintln(writer, "Type\tPattern")
+		fmt.Fprintln(writer, "-------\t-------")
+		for _, filter := range filters {
+			fmt.Fprintln(writer, filter.TabbedString())
+		}
+		writer.Flush()
+	} else {
+		log.Printf("No object filters found for database '%s'.\n", databaseName)
+	}
+}
+
+// Add an object filter to a database.
+func AddObjectFilter(databaseName string, filterType string, pattern string) {
+
+	database.AssertConfigDatabaseExists()
+
+	if !database.ValidFilterPattern(pattern) {
+		log.Fatalf("Pattern '%s' is not valid.\n", pattern)
+	}
+
+	database.AddObjectFilter(databaseName, filterType, pattern)
+	log.Println("Filter added successfully.")
+}
+
+// Remove an object filter from a database.
+func RemoveObjectFilter(databaseName string, filterType string, pattern string) {
+
+	database.AssertConfigDatabaseExists()
+
+	if !database.RemoveObjectFilter(databaseName, filterType, pattern) {
+		log.Fatalf("Database '%s' does not have an %s filter '%s'.\n", databaseName, filterType, pattern)
+	}
+	log.Println("Filter removed successfully.")
+}
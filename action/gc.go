@@ -0,0 +1,45 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "os"
+import "text/tabwriter"
+import "time"
+
+// List temporary databases left behind by earlier runs and drop any that are 
+// orphaned, unless this is a dry run. Unconfirmed temporary databases are only 
+// dropped if forced.
+func CollectGarbage(olderThan time.Duration, dryRun bool, force bool) {
+
+	database.AssertConfigDatabaseExists()
+
+	temps := database.GetTempDatabases(olderThan)
+
+	if len(temps) == 0 {
+		log.Println("No temporary databases found.")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+	fmt.Fprintln(writer, "Database\tOwner\tHost\tCreated\tState")
+	fmt.Fprintln(writer, "--------\t-----\t----\t-------\t-----")
+	for _, temp := range temps {
+		fmt.Fprintln(writer, temp.TabbedString())
+	}
+	writer.Flush()
+
+	if dryRun {
+		return
+	}
+
+	dropped := 0
+	for _, temp := range temps {
+		if temp.State == database.TEMP_ORPHANED || (force && temp.State == database.TEMP_UNCONFIRMED) {
+			database.DropTempDatabase(temp.Name)
+			dropped++
+		}
+	}
+	log.Printf("Dropped %d temporary database(s).\n", dropped)
+}
@@ -0,0 +1,107 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
+import "log"
+import "regexp"
+import "strings"
+
+// Sections of a revision's stored SQL that are searched.
+const SECTION_UP string = "up"
+const SECTION_DOWN string = "down"
+const SECTION_FULL string = "full"
+
+// A line of a revision's SQL matching a search pattern.
+type grepMatch struct {
+	Revision uint64 `json:"revision" yaml:"revision"`
+	Section string `json:"section" yaml:"section"`
+	Line int `json:"line" yaml:"line"`
+	Text string `json:"text" yaml:"text"`
+}
+
+// The results of searching a database's history in machine readable output.
+type grepDocument struct {
+	Database string `json:"database" yaml:"database"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Matches []grepMatch `json:"matches" yaml:"matches"`
+}
+
+// Document interface implementation.
+func (this grepDocument) Kind() (string) {
+	return "grep"
+}
+
+// Document interface implementation.
+func (this grepDocument) CsvHeader() ([]string) {
+	return []string{"database", "revision", "section", "line", "text"}
+}
+
+// Document interface implementation.
+func (this grepDocument) CsvRows() (rows [][]string) {
+	for _, match := range this.Matches {
+		rows = append(rows, []string{this.Database, formatUint(match.Revision), match.Section, fmt.Sprintf("%d", match.Line), match.Text})
+	}
+	return
+}
+
+// Search the up, down and full SQL of a database's revisions for lines 
+// matching a regular expression. Every revision in range is read from the 
+// config database, those not containing any literal text the pattern starts 
+// with are skipped and the rest are searched line by line.
+func Grep(databaseName string, pattern string, revisionString string, ignoreCase bool, format string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	expression := pattern
+	if ignoreCase {
+		expression = "(?i)" + expression
+	}
+	matcher, err := regexp.Compile(expression)
+	if err != nil {
+		log.Fatalf("Pattern '%s' is not a valid regular expression: %s\n", pattern, err)
+	}
+
+	var from, to uint64
+	if revisionString != "" {
+		from, to = parseRevisions(revisionString)
+		if !strings.Contains(revisionString, "..") {
+			to = from
+		}
+	}
+
+	literal, _ := regexp.MustCompile(pattern).LiteralPrefix()
+	matches    := make([]grepMatch, 0)
+
+	for _, revision := range database.SearchRevisionSql(databaseName, from, to, literal) {
+		matches = append(matches, grepSql(matcher, revision.Revision, SECTION_UP, revision.UpSql)...)
+		matches = append(matches, grepSql(matcher, revision.Revision, SECTION_DOWN, revision.DownSql)...)
+		matches = append(matches, grepSql(matcher, revision.Revision, SECTION_FULL, revision.FullSql)...)
+	}
+
+	if !output.IsText(format) {
+		output.Write(format, grepDocument{databaseName, pattern, matches})
+		return
+	}
+
+	if len(matches) > 0 {
+		for _, match := range matches {
+			fmt.Printf("%d:%s:%d:%s\n", match.Revision, match.Section, match.Line, match.Text)
+		}
+	} else {
+		log.Printf("No matches found for '%s' in database '%s'.\n", pattern, databaseName)
+	}
+}
+
+// Return the lines of SQL matching a regular expression.
+func grepSql(matcher *regexp.Regexp, revision uint64, section string, sql string) ([]grepMatch) {
+	matches := make([]grepMatch, 0)
+	for index, line := range strings.Split(sql, "\n") {
+		if matcher.MatchString(line) {
+			matches = append(matches, grepMatch{revision, section, index + 1, line})
+		}
+	}
+	return matches
+}
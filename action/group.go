@@ -0,0 +1,192 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "os"
+import "sync"
+import "text/tabwriter"
+
+// List all groups.
+func ListGroups() {
+
+	database.AssertConfigDatabaseExists()
+
+	groups := database.GetGroups()
+
+	if len(groups) > 0 {
+		writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+		fmt.Fprintln(writer, "Group\tHistory\tDatabases\tCreated")
+		fmt.Fprintln(writer, "-----\t-------\t---------\t-------")
+		for _, group := range groups {
+			fmt.Fprintln(writer, group.TabbedString())
+		}
+		writer.Flush()
+	} else {
+		log.Println("No groups found.")
+	}
+}
+
+// List the databases in a group.
+func ListGroupMembers(group string) {
+
+	database.AssertConfigDatabaseExists()
+
+	writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+	fmt.Fprintln(writer, "Database\tRole\tRevision")
+	fmt.Fprintln(writer, "--------\t----\t--------")
+	for _, member := range database.GetGroupMembers(group) {
+		fmt.Fprintln(writer, member.TabbedString())
+	}
+	writer.Flush()
+}
+
+// Create a group sharing the history of a managed database.
+func CreateGroup(group string, databaseName string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	database.CreateGroup(group, databaseName)
+	log.Println("Group created successfully.")
+}
+
+// Delete a group once all members have been removed.
+func DeleteGroup(group string) {
+
+	database.AssertConfigDatabaseExists()
+
+	if !database.DeleteGroup(group) {
+		log.Fatalf("Group '%s' still has members, remove them first.\n", group)
+	}
+	log.Println("Group deleted successfully.")
+}
+
+// Add a database to a group. If no revision is passed the revision of the 
+// group's history matching the schema of the database is used.
+func AddGroupMember(group string, databaseName string, revision uint64) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	history := database.AssertGroupExists(group)
+	head    := database.GetHeadRevision(history)
+
+	if revision > head {
+		log.Fatalf("Group '%s' does not have a revision '%d'.\n", group, revision)
+	}
+
+	if revision == 0 {
+		detected, found := database.DetectGroupRevision(group, databaseName)
+		if !found {
+			log.Printf("The schema of database '%s' does not match any revision of group '%s'.\n", databaseName, group)
+			log.Fatalln("Specify the revision the database is at to add it anyway.")
+		}
+		revision = detected
+	}
+
+	database.AddGroupMember(group, databaseName, revision)
+	log.Printf("Database '%s' added to group '%s' at revision '%d'.\n", databaseName, group, revision)
+}
+
+// Remove a database from a group.
+func RemoveGroupMember(group string, databaseName string) {
+
+	database.AssertConfigDatabaseExists()
+
+	if !database.RemoveGroupMember(group, databaseName) {
+		log.Fatalf("Database '%s' is not a member of group '%s' that can be removed.\n", databaseName, group)
+	}
+	log.Println("Database removed from group successfully.")
+}
+
+// Update every database in a group to a particular revision. Each database is 
+// updated by running snap's update command in a separate process, so each has 
+// its own connection and can be resumed separately if it fails. Up to the 
+// passed number of databases are updated in parallel. Once the passed number 
+// of updates have failed no more are started, unless it's zero. A summary of 
+// the outcome for each database is shown at the end.
+func UpdateGroup(group string, target uint64, parallel int, maxFailures int, backup bool) {
+
+	database.AssertConfigDatabaseExists()
+
+	history := database.AssertGroupExists(group)
+	head    := database.GetHeadRevision(history)
+	members := database.GetGroupMembers(group)
+
+	if target == 0 {
+		target = head
+	}
+
+	if target > head {
+		log.Fatalf("Group '%s' does not have a revision '%d'.\n", group, target)
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.Println(err)
+		log.Fatalln("Can not find the snap executable to update the group's databases.")
+	}
+
+	updates := make([]databaseUpdate, len(members))
+	for index, member := range members {
+		updates[index] = databaseUpdate{Name: member.Name, From: member.Revision, To: target}
+		updates[index].Outcome = UPDATE_PENDING
+		if member.Revision == target {
+			updates[index].Outcome = UPDATE_NOT_NEEDED
+		}
+	}
+
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+	failures := 0
+	jobs     := make(chan int)
+
+	for worker := 0; worker < parallel; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for index := range jobs {
+				update := &updates[index]
+
+				lock.Lock()
+				stop := maxFailures > 0 && failures >= maxFailures
+				lock.Unlock()
+
+				if stop {
+					update.Outcome = UPDATE_SKIPPED
+					continue
+				}
+
+				log.Printf("Updating database '%s' from revision '%d' to '%d'.\n", update.Name, update.From, update.To)
+				update.Outcome, update.Message = runUpdateProcess(executable, update.Name, target, backup)
+
+				if update.Outcome == UPDATE_FAILED {
+					lock.Lock()
+					failures++
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+
+	for index := range updates {
+		if updates[index].Outcome == UPDATE_PENDING {
+			jobs <- index
+		}
+	}
+	close(jobs)
+	wait.Wait()
+
+	printDatabaseUpdates(updates)
+
+	if failures > 0 {
+		log.Fatalf("%d of %d database(s) in group '%s' failed to update.\n", failures, len(updates), group)
+	}
+	log.Println("Group updated successfully.")
+}
@@ -0,0 +1,34 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/lint"
+import "log"
+
+// Check a snap file for common problems and display the results.
+func LintFile(file string, databaseName string, allowDestructive bool) {
+
+	if databaseName != "" {
+		database.AssertConfigDatabaseExists()
+		database.AssertDatabaseExists(databaseName)
+	}
+
+	validateSqlFileFormat(file)
+
+	if !lintFile(file, databaseName, allowDestructive) {
+		log.Fatalf("File '%s' contains lint errors.\n", file)
+	}
+
+	log.Println("File linted successfully.")
+}
+
+// Lint a snap file and display the results. Returns false if any errors were 
+// found.
+func lintFile(file string, databaseName string, allowDestructive bool) (bool) {
+	results := lint.LintFile(file, databaseName, allowDestructive)
+	for _, result := range results {
+		fmt.Printf("%s:%s\n", file, result)
+	}
+	return !results.HasErrors()
+}
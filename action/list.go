@@ -3,16 +3,54 @@ package action
 // Imports.
 import "fmt"
 import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
 import "log"
 import "os"
+import "strconv"
 import "strings"
 import "text/tabwriter"
 
+// A managed database in machine readable output.
+type databaseDocument struct {
+	Name string `json:"name" yaml:"name"`
+	CurrentRevision uint64 `json:"currentRevision" yaml:"currentRevision"`
+	HeadRevision uint64 `json:"headRevision" yaml:"headRevision"`
+	Initialised string `json:"initialised" yaml:"initialised"`
+}
+
+// The list of managed databases in machine readable output.
+type databaseListDocument struct {
+	Databases []databaseDocument `json:"databases" yaml:"databases"`
+}
+
+// Document interface implementation.
+func (this databaseListDocument) Kind() (string) {
+	return "databases"
+}
+
+// Document interface implementation.
+func (this databaseListDocument) CsvHeader() ([]string) {
+	return []string{"name", "currentRevision", "headRevision", "initialised"}
+}
+
+// Document interface implementation.
+func (this databaseListDocument) CsvRows() (rows [][]string) {
+	for _, entry := range this.Databases {
+		rows = append(rows, []string{entry.Name, formatUint(entry.CurrentRevision), formatUint(entry.HeadRevision), entry.Initialised})
+	}
+	return
+}
+
 // List all managed databases.
-func ListManagedDatabases() {
+func ListManagedDatabases(format string) {
 
 	database.AssertConfigDatabaseExists()
 
+	if !output.IsText(format) {
+		output.Write(format, newDatabaseListDocument())
+		return
+	}
+
 	list := database.GetManagedDatabaseList()
 
 	if len(list) > 0 {
@@ -32,3 +70,24 @@ func ListManagedDatabases() {
 		log.Println("No databases are currently being managed.")
 	}
 }
+
+// Create the list of managed databases.
+func newDatabaseListDocument() (databaseListDocument) {
+	document := databaseListDocument{make([]databaseDocument, 0)}
+	for _, entry := range database.GetManagedDatabaseList() {
+		document.Databases = append(document.Databases, databaseDocument{entry.Name, parseUint(entry.Current), parseUint(entry.Revision), entry.Date})
+	}
+	return document
+}
+
+// Parse a revision read from the database. Revisions are always valid numbers 
+// so any error is ignored.
+func parseUint(value string) (uint64) {
+	number, _ := strconv.ParseUint(value, 10, 64)
+	return number
+}
+
+// Format a revision for writing as Csv.
+func formatUint(value uint64) (string) {
+	return strconv.FormatUint(value, 10)
+}
@@ -3,26 +3,189 @@ package action
 // Imports.
 import "fmt"
 import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
+import "github.com/nomad-software/snap/sanitise"
 import "log"
+import "strings"
+import "time"
+
+// Layouts accepted for the dates used to filter the log.
+const DATE_LAYOUT string = "2006-01-02"
+const DATE_TIME_LAYOUT string = "2006-01-02 15:04:05"
+
+// Options controlling which log entries are shown and how.
+type LogOptions struct {
+	Author string
+	Since string
+	Until string
+	Grep string
+	Range string
+	Object string
+	Pickaxe string
+	Limit uint64
+	OneLine bool
+	Stat bool
+	Patch bool
+}
+
+// An object changed by a revision in machine readable output.
+type objectChangeDocument struct {
+	Operation string `json:"operation" yaml:"operation"`
+	Type string `json:"type" yaml:"type"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// A log entry in machine readable output.
+type logEntryDocument struct {
+	Revision uint64 `json:"revision" yaml:"revision"`
+	Author string `json:"author" yaml:"author"`
+	Date string `json:"date" yaml:"date"`
+	Comment string `json:"comment" yaml:"comment"`
+	Objects []objectChangeDocument `json:"objects,omitempty" yaml:"objects,omitempty"`
+	UpSql string `json:"upSql,omitempty" yaml:"upSql,omitempty"`
+}
+
+// The log of a managed database in machine readable output.
+type logDocument struct {
+	Database string `json:"database" yaml:"database"`
+	CurrentRevision uint64 `json:"currentRevision" yaml:"currentRevision"`
+	HeadRevision uint64 `json:"headRevision" yaml:"headRevision"`
+	Entries []logEntryDocument `json:"entries" yaml:"entries"`
+}
+
+// Document interface implementation.
+func (this logDocument) Kind() (string) {
+	return "log"
+}
+
+// Document interface implementation.
+func (this logDocument) CsvHeader() ([]string) {
+	return []string{"database", "revision", "author", "date", "comment"}
+}
+
+// Document interface implementation.
+func (this logDocument) CsvRows() (rows [][]string) {
+	for _, entry := range this.Entries {
+		rows = append(rows, []string{this.Database, formatUint(entry.Revision), entry.Author, entry.Date, entry.Comment})
+	}
+	return
+}
 
 // Show the commit log for the passed database.
-func ShowLog(databaseName string) {
+func ShowLog(databaseName string, options LogOptions, format string) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
 
-	logEntries := database.GetLogEntries(databaseName)
+	filter, err := options.filter()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if !output.IsText(format) {
+		output.Write(format, newLogDocument(databaseName, filter, options))
+		return
+	}
+
+	logEntries := database.GetLogEntries(databaseName, filter)
 
 	if len(logEntries) > 0 {
 		for _, entry := range logEntries {
-			fmt.Printf("Revision: %s\n", entry.Revision)
-			fmt.Printf("Author: %s\n", entry.Author)
-			fmt.Printf("Date: %s\n", entry.Date)
-			fmt.Println("")
-			fmt.Printf("    %s\n", entry.Comment)
-			fmt.Println("")
+			if options.OneLine {
+				fmt.Printf("%s %s\n", entry.Revision, strings.SplitN(entry.Comment, "\n", 2)[0])
+			} else {
+				fmt.Printf("Revision: %s\n", entry.Revision)
+				fmt.Printf("Author: %s\n", entry.Author)
+				fmt.Printf("Date: %s\n", entry.Date)
+				fmt.Println("")
+				fmt.Printf("    %s\n", entry.Comment)
+				fmt.Println("")
+			}
+			if options.Stat {
+				for _, change := range sanitise.ObjectChanges(entry.UpSql) {
+					fmt.Printf(" %s %s %s\n", strings.ToLower(change.Operation), strings.ToLower(change.Type), change.Name)
+				}
+				fmt.Println("")
+			}
+			if options.Patch {
+				fmt.Println(strings.TrimSpace(entry.UpSql))
+				fmt.Println("")
+			}
 		}
 	} else {
 		log.Printf("No log entries found for database '%s'.\n", databaseName)
 	}
 }
+
+// Create the filter used to read log entries from the database. An error is 
+// returned if a date, the revision range or the grep pattern can't be 
+// recognised. The grep pattern is checked by the database server.
+func (this LogOptions) filter() (filter database.LogFilter, err error) {
+	filter = database.LogFilter{
+		Author: this.Author,
+		Grep: this.Grep,
+		Object: this.Object,
+		Pickaxe: this.Pickaxe,
+		Limit: this.Limit,
+		IncludeSql: this.Stat || this.Patch,
+	}
+	if filter.Since, err = parseLogDate(this.Since, "00:00:00"); err != nil {
+		return
+	}
+	if filter.Until, err = parseLogDate(this.Until, "23:59:59"); err != nil {
+		return
+	}
+	if this.Range != "" {
+		if filter.From, filter.To, err = splitRevisions(this.Range); err != nil {
+			return
+		}
+		if !strings.Contains(this.Range, "..") {
+			filter.To = filter.From
+		}
+	}
+	if this.Grep != "" {
+		if err = database.CheckRegexp(this.Grep); err != nil {
+			err = fmt.Errorf("Pattern '%s' is not a valid regular expression: %s", this.Grep, err)
+		}
+	}
+	return
+}
+
+// Create the log of a managed database for machine readable output.
+func newLogDocument(databaseName string, filter database.LogFilter, options LogOptions) (logDocument) {
+	document := logDocument{
+		Database: databaseName,
+		CurrentRevision: database.GetCurrentSchemaRevision(databaseName),
+		HeadRevision: database.GetHeadRevision(databaseName),
+		Entries: make([]logEntryDocument, 0),
+	}
+	for _, entry := range database.GetLogEntries(databaseName, filter) {
+		entryDocument := logEntryDocument{Revision: parseUint(entry.Revision), Author: entry.Author, Date: entry.Date, Comment: entry.Comment}
+		if options.Stat {
+			entryDocument.Objects = make([]objectChangeDocument, 0)
+			for _, change := range sanitise.ObjectChanges(entry.UpSql) {
+				entryDocument.Objects = append(entryDocument.Objects, objectChangeDocument{strings.ToLower(change.Operation), strings.ToLower(change.Type), change.Name})
+			}
+		}
+		if options.Patch {
+			entryDocument.UpSql = entry.UpSql
+		}
+		document.Entries = append(document.Entries, entryDocument)
+	}
+	return document
+}
+
+// Parse a date used to filter the log, returning it in the format used by the 
+// database. If only a date is passed the passed time is added to it.
+func parseLogDate(date string, defaultTime string) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+	if parsed, err := time.Parse(DATE_TIME_LAYOUT, date); err == nil {
+		return parsed.Format(DATE_TIME_LAYOUT), nil
+	}
+	if parsed, err := time.Parse(DATE_LAYOUT, date); err == nil {
+		return parsed.Format(DATE_LAYOUT) + " " + defaultTime, nil
+	}
+	return "", fmt.Errorf("Date '%s' is not recognised, use the format 'YYYY-MM-DD' or 'YYYY-MM-DD hh:mm:ss'.", date)
+}
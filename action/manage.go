@@ -0,0 +1,62 @@
+package action
+
+// Imports.
+import "github.com/nomad-software/snap/config"
+import "github.com/nomad-software/snap/database"
+import "log"
+import "strings"
+
+// Stop managing a database, optionally archiving its history. The database 
+// doesn't need to exist so a dropped database can be removed from management.
+func UninitialiseDatabase(databaseName string, archive bool) {
+
+	database.AssertConfigDatabaseExists()
+
+	if !database.DatabaseIsManaged(databaseName) {
+		log.Fatalf("Database '%s' is not currently being managed.\n", databaseName)
+	}
+
+	backups := make([]string, 0)
+	for _, backup := range database.GetBackups(databaseName) {
+		backups = append(backups, backup.Database)
+	}
+
+	database.UninitialiseDatabase(databaseName, archive)
+
+	if archive {
+		log.Printf("History of database '%s' archived.\n", databaseName)
+	}
+	if len(backups) > 0 {
+		log.Printf("Backup database(s) '%s' are no longer recorded and can be dropped by hand.\n", strings.Join(backups, "', '"))
+	}
+	log.Println("Database uninitialised successfully.")
+}
+
+// Move the history of a managed database to a database it has been renamed to.
+func RenameDatabase(oldName string, newName string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(newName)
+
+	if !database.DatabaseIsManaged(oldName) {
+		log.Fatalf("Database '%s' is not currently being managed.\n", oldName)
+	}
+
+	database.RenameDatabase(oldName, newName)
+
+	if _, configured := config.GetConfig().Databases[oldName]; configured {
+		log.Printf("Settings for database '%s' in the config file must be moved to '%s' by hand.\n", oldName, newName)
+	}
+	log.Printf("History of database '%s' moved to '%s' successfully.\n", oldName, newName)
+}
+
+// Replace the history of a managed database with a single revision holding its 
+// current schema, archiving the old revisions.
+func RebaselineDatabase(databaseName string, comment string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	previous := database.RebaselineDatabase(databaseName, comment)
+	log.Printf("Database rebaselined successfully, revisions 1 to %d archived.\n", previous)
+}
@@ -0,0 +1,190 @@
+package action
+
+// The OpenAPI description of the API served by the serve command. Every 
+// successful response is wrapped in the same envelope as machine readable 
+// output written by the other commands.
+const OPEN_API_DESCRIPTION string = `{
+    "openapi": "3.0.3",
+    "info": {
+        "title": "Snap",
+        "description": "Read the schema history of databases managed by snap and, if enabled, commit and apply schema updates.",
+        "version": "1"
+    },
+    "servers": [{"url": "/v1"}],
+    "components": {
+        "securitySchemes": {
+            "token": {"type": "http", "scheme": "bearer"}
+        },
+        "parameters": {
+            "database": {"name": "database", "in": "path", "required": true, "schema": {"type": "string"}, "description": "The name of a managed database."}
+        },
+        "schemas": {
+            "Envelope": {
+                "type": "object",
+                "properties": {
+                    "schemaVersion": {"type": "integer"},
+                    "kind": {"type": "string"},
+                    "data": {"type": "object"}
+                }
+            },
+            "Error": {
+                "type": "object",
+                "properties": {
+                    "error": {"type": "string"}
+                }
+            }
+        },
+        "responses": {
+            "Document": {
+                "description": "A document wrapped in an envelope.",
+                "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Envelope"}}}
+            },
+            "Error": {
+                "description": "An error.",
+                "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+            }
+        }
+    },
+    "paths": {
+        "/databases": {
+            "get": {
+                "summary": "List all managed databases.",
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/status": {
+            "get": {
+                "summary": "Show the current and latest revisions of a database and any interrupted commit or update.",
+                "parameters": [{"$ref": "#/components/parameters/database"}],
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/log": {
+            "get": {
+                "summary": "Show the log of schema update commits, newest first.",
+                "parameters": [
+                    {"$ref": "#/components/parameters/database"},
+                    {"name": "author", "in": "query", "schema": {"type": "string"}, "description": "Only show commits whose author contains this text."},
+                    {"name": "since", "in": "query", "schema": {"type": "string"}, "description": "Only show commits made on or after this date, as YYYY-MM-DD or YYYY-MM-DD hh:mm:ss."},
+                    {"name": "until", "in": "query", "schema": {"type": "string"}, "description": "Only show commits made on or before this date."},
+                    {"name": "grep", "in": "query", "schema": {"type": "string"}, "description": "Only show commits whose comment matches this regular expression, in the REGEXP syntax of the database server."},
+                    {"name": "revisions", "in": "query", "schema": {"type": "string"}, "description": "Only show this revision or range of revisions, as from[..to]."},
+                    {"name": "object", "in": "query", "schema": {"type": "string"}, "description": "Only show commits that changed this object."},
+                    {"name": "S", "in": "query", "schema": {"type": "string"}, "description": "Only show commits that changed the number of times this string occurs in the full schema."},
+                    {"name": "n", "in": "query", "schema": {"type": "integer"}, "description": "Only show this many commits."},
+                    {"name": "stat", "in": "query", "schema": {"type": "boolean"}, "description": "Include the objects changed by each commit."},
+                    {"name": "patch", "in": "query", "schema": {"type": "boolean"}, "description": "Include the update SQL of each commit."}
+                ],
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "400": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/revisions/{revision}": {
+            "get": {
+                "summary": "Show the update and down SQL of a revision.",
+                "parameters": [
+                    {"$ref": "#/components/parameters/database"},
+                    {"name": "revision", "in": "path", "required": true, "schema": {"type": "string"}, "description": "A revision number or head."}
+                ],
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "400": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/schema": {
+            "get": {
+                "summary": "Show the full schema at a revision.",
+                "parameters": [
+                    {"$ref": "#/components/parameters/database"},
+                    {"name": "revision", "in": "query", "schema": {"type": "string"}, "description": "A revision number or head, which is the default."}
+                ],
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "400": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/diff": {
+            "get": {
+                "summary": "Show a unified diff between the full schemas of two revisions.",
+                "parameters": [
+                    {"$ref": "#/components/parameters/database"},
+                    {"name": "revisions", "in": "query", "required": true, "schema": {"type": "string"}, "description": "The revisions to diff, as from[..to]. The to revision defaults to the latest revision."}
+                ],
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "400": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/commit": {
+            "post": {
+                "summary": "Commit a snap file as a new revision.",
+                "security": [{"token": []}],
+                "parameters": [{"$ref": "#/components/parameters/database"}],
+                "requestBody": {
+                    "required": true,
+                    "content": {"application/json": {"schema": {
+                        "type": "object",
+                        "required": ["sql", "comment"],
+                        "properties": {
+                            "sql": {"type": "string", "description": "The contents of the snap file."},
+                            "comment": {"type": "string"},
+                            "allowDestructive": {"type": "boolean"}
+                        }
+                    }}}
+                },
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "401": {"$ref": "#/components/responses/Error"},
+                    "403": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "422": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        },
+        "/databases/{database}/update": {
+            "post": {
+                "summary": "Update a database to a revision.",
+                "security": [{"token": []}],
+                "parameters": [{"$ref": "#/components/parameters/database"}],
+                "requestBody": {
+                    "content": {"application/json": {"schema": {
+                        "type": "object",
+                        "properties": {
+                            "revision": {"type": "integer", "description": "The revision to update to. Zero or absent updates to the latest revision."}
+                        }
+                    }}}
+                },
+                "responses": {
+                    "200": {"$ref": "#/components/responses/Document"},
+                    "401": {"$ref": "#/components/responses/Error"},
+                    "403": {"$ref": "#/components/responses/Error"},
+                    "404": {"$ref": "#/components/responses/Error"},
+                    "422": {"$ref": "#/components/responses/Error"},
+                    "500": {"$ref": "#/components/responses/Error"}
+                }
+            }
+        }
+    }
+}
+`
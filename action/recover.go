@@ -0,0 +1,28 @@
+package action
+
+// Imports.
+import "github.com/nomad-software/snap/database"
+import "log"
+
+// Recover a revision left pending by an interrupted commit.
+func RecoverPendingRevision(databaseName string, mode string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+	database.AssertHasOwnHistory(databaseName)
+
+	outcome, revision, err := database.RecoverPendingRevision(databaseName, mode)
+	if err != nil {
+		log.Println(err)
+		log.Fatalf("Revision '%d' of database '%s' could not be recovered.\n", revision, databaseName)
+	}
+
+	switch outcome {
+		case database.RECOVERY_NONE:
+			log.Printf("Database '%s' has no pending revisions.\n", databaseName)
+		case database.RECOVERY_FINALISED:
+			log.Printf("Revision '%d' finalised successfully.\n", revision)
+		case database.RECOVERY_ROLLED_BACK:
+			log.Printf("Revision '%d' rolled back successfully.\n", revision)
+	}
+}
@@ -0,0 +1,475 @@
+package action
+
+// Imports.
+import "crypto/subtle"
+import "encoding/json"
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "github.com/nomad-software/snap/output"
+import "github.com/nomad-software/snap/ui"
+import "io/ioutil"
+import "log"
+import "net/http"
+import "os"
+import "os/exec"
+import "strconv"
+import "strings"
+import "sync"
+
+// The prefix of every API endpoint.
+const API_PREFIX string = "/v1"
+
+// The environment variable holding the token that authorises write requests.
+const API_TOKEN_VARIABLE string = "SNAP_API_TOKEN"
+
+// The largest request body accepted, which limits the size of committed files.
+const MAX_REQUEST_SIZE int64 = 10 << 20
+
+// The status of a managed database in machine readable output.
+type statusDocument struct {
+	Database string `json:"database" yaml:"database"`
+	CurrentRevision uint64 `json:"currentRevision" yaml:"currentRevision"`
+	HeadRevision uint64 `json:"headRevision" yaml:"headRevision"`
+	UpToDate bool `json:"upToDate" yaml:"upToDate"`
+	PendingRevision uint64 `json:"pendingRevision,omitempty" yaml:"pendingRevision,omitempty"`
+	InterruptedUpdate *interruptedUpdateDocument `json:"interruptedUpdate,omitempty" yaml:"interruptedUpdate,omitempty"`
+}
+
+// An interrupted update in machine readable output.
+type interruptedUpdateDocument struct {
+	Revision uint64 `json:"revision" yaml:"revision"`
+	Direction string `json:"direction" yaml:"direction"`
+	Statement uint64 `json:"statement" yaml:"statement"`
+	Error string `json:"error" yaml:"error"`
+}
+
+// Document interface implementation.
+func (this statusDocument) Kind() (string) {
+	return "status"
+}
+
+// Document interface implementation.
+func (this statusDocument) CsvHeader() ([]string) {
+	return []string{"database", "currentRevision", "headRevision", "upToDate"}
+}
+
+// Document interface implementation.
+func (this statusDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Database, formatUint(this.CurrentRevision), formatUint(this.HeadRevision), strconv.FormatBool(this.UpToDate)}}
+}
+
+// The result of a write request in machine readable output.
+type writeDocument struct {
+	Database string `json:"database" yaml:"database"`
+	Operation string `json:"operation" yaml:"operation"`
+	Output string `json:"output" yaml:"output"`
+}
+
+// Document interface implementation.
+func (this writeDocument) Kind() (string) {
+	return this.Operation
+}
+
+// Document interface implementation.
+func (this writeDocument) CsvHeader() ([]string) {
+	return []string{"database", "operation", "output"}
+}
+
+// Document interface implementation.
+func (this writeDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Database, this.Operation, this.Output}}
+}
+
+// The body of a commit request.
+type commitRequest struct {
+	Sql string `json:"sql"`
+	Comment string `json:"comment"`
+	AllowDestructive bool `json:"allowDestructive"`
+}
+
+// The body of an update request.
+type updateRequest struct {
+	Revision uint64 `json:"revision"`
+}
+
+// A server exposing the history of managed databases over HTTP. The database 
+// package uses a single connection so requests reading it are handled one at 
+// a time. Write requests run the snap executable in a separate process, as the 
+// group and apply commands do, so a failed commit or update can't halt the 
+// server. Write requests are also handled one at a time. Requests outside the 
+// API are passed to the web UI if it's enabled.
+type apiServer struct {
+	Token string
+	Executable string
+	Ui http.Handler
+	ReadLock sync.Mutex
+	WriteLock sync.Mutex
+}
+
+// Serve the API and, if enabled, the web UI on the passed address. Write 
+// requests are only accepted if enabled, in which case they must carry the 
+// token held in the environment.
+func Serve(listen string, allowWrites bool, enableUi bool) {
+
+	database.AssertConfigDatabaseExists()
+
+	server := &apiServer{}
+
+	if enableUi {
+		server.Ui = ui.Handler()
+	}
+
+	if allowWrites {
+		server.Token = os.Getenv(API_TOKEN_VARIABLE)
+		if server.Token == "" {
+			log.Fatalf("Write requests can only be enabled if a token is set in the '%s' environment variable.\n", API_TOKEN_VARIABLE)
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			log.Println(err)
+			log.Fatalln("Can not find the snap executable to handle write requests.")
+		}
+		server.Executable = executable
+	}
+
+	log.Printf("Serving the snap API on '%s'.\n", listen)
+	err := http.ListenAndServe(listen, server)
+	log.Println(err)
+	log.Fatalf("Can not serve the snap API on '%s'.\n", listen)
+}
+
+// Route a request to the handler of its endpoint.
+func (this *apiServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	if path == API_PREFIX + "/openapi.json" {
+		this.handleOpenApi(writer, request)
+		return
+	}
+
+	if !strings.HasPrefix(path, API_PREFIX + "/") && this.Ui != nil {
+		this.Ui.ServeHTTP(writer, request)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, API_PREFIX + "/"), "/")
+	if !strings.HasPrefix(path, API_PREFIX + "/") || parts[0] != "databases" {
+		writeApiError(writer, http.StatusNotFound, "No such endpoint '%s'.", request.URL.Path)
+		return
+	}
+
+	switch {
+		case len(parts) == 1:
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newDatabaseListDocument(), http.StatusOK, nil
+			})
+
+		case len(parts) == 3 && parts[2] == "status":
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newStatusDocument(parts[1])
+			})
+
+		case len(parts) == 3 && parts[2] == "log":
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newApiLogDocument(parts[1], request)
+			})
+
+		case len(parts) == 4 && parts[2] == "revisions":
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newApiRevisionDocument(parts[1], parts[3])
+			})
+
+		case len(parts) == 3 && parts[2] == "schema":
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newApiSchemaDocument(parts[1], request.URL.Query().Get("revision"))
+			})
+
+		case len(parts) == 3 && parts[2] == "diff":
+			this.handleRead(writer, request, func() (output.Document, int, error) {
+				return newApiDiffDocument(parts[1], request.URL.Query().Get("revisions"))
+			})
+
+		case len(parts) == 3 && parts[2] == "commit":
+			this.handleWrite(writer, request, parts[1], "commit", this.commit)
+
+		case len(parts) == 3 && parts[2] == "update":
+			this.handleWrite(writer, request, parts[1], "update", this.update)
+
+		default:
+			writeApiError(writer, http.StatusNotFound, "No such endpoint '%s'.", request.URL.Path)
+	}
+}
+
+// Serve the OpenAPI description of the API.
+func (this *apiServer) handleOpenApi(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writeApiError(writer, http.StatusMethodNotAllowed, "Method '%s' is not allowed.", request.Method)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(writer, OPEN_API_DESCRIPTION)
+}
+
+// Handle a request reading from the database. Only one request reads from the 
+// database at a time. Reads are tried so a failure responds with an error 
+// instead of halting the server.
+func (this *apiServer) handleRead(writer http.ResponseWriter, request *http.Request, read func() (output.Document, int, error)) {
+	if request.Method != http.MethodGet {
+		writeApiError(writer, http.StatusMethodNotAllowed, "Method '%s' is not allowed.", request.Method)
+		return
+	}
+
+	var document output.Document
+	var status int
+	var err error
+
+	this.ReadLock.Lock()
+	failure := database.Try(func() {
+		document, status, err = read()
+	})
+	this.ReadLock.Unlock()
+
+	if failure != nil {
+		writeApiError(writer, http.StatusInternalServerError, "%s", failure)
+		return
+	}
+	if err != nil {
+		writeApiError(writer, status, "%s", err)
+		return
+	}
+	writeApiDocument(writer, status, document)
+}
+
+// Handle a request writing to a managed database. The request must carry the 
+// token as a bearer token.
+func (this *apiServer) handleWrite(writer http.ResponseWriter, request *http.Request, databaseName string, operation string, write func(string, []byte) ([]byte, error)) {
+	if request.Method != http.MethodPost {
+		writeApiError(writer, http.StatusMethodNotAllowed, "Method '%s' is not allowed.", request.Method)
+		return
+	}
+	if this.Token == "" {
+		writeApiError(writer, http.StatusForbidden, "Write requests are not enabled.")
+		return
+	}
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(this.Token)) != 1 {
+		writeApiError(writer, http.StatusUnauthorized, "A valid bearer token is required.")
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(writer, request.Body, MAX_REQUEST_SIZE))
+	if err != nil {
+		writeApiError(writer, http.StatusBadRequest, "Can not read the request body.")
+		return
+	}
+
+	var managed bool
+
+	this.ReadLock.Lock()
+	failure := database.Try(func() {
+		managed = database.DatabaseIsManaged(databaseName)
+	})
+	this.ReadLock.Unlock()
+
+	if failure != nil {
+		writeApiError(writer, http.StatusInternalServerError, "%s", failure)
+		return
+	}
+	if !managed {
+		writeApiError(writer, http.StatusNotFound, "Database '%s' is not currently being managed.", databaseName)
+		return
+	}
+
+	this.WriteLock.Lock()
+	result, err := write(databaseName, body)
+	this.WriteLock.Unlock()
+
+	if err != nil {
+		writeApiError(writer, http.StatusUnprocessableEntity, "%s", strings.TrimSpace(string(result) + "\n" + err.Error()))
+		return
+	}
+	writeApiDocument(writer, http.StatusOK, writeDocument{databaseName, operation, string(result)})
+}
+
+// Commit a snap file to a managed database by running the commit command.
+func (this *apiServer) commit(databaseName string, body []byte) ([]byte, error) {
+	var commit commitRequest
+	if err := json.Unmarshal(body, &commit); err != nil {
+		return nil, fmt.Errorf("The request body is not valid Json: %s", err)
+	}
+	if commit.Sql == "" || commit.Comment == "" {
+		return nil, fmt.Errorf("Both 'sql' and 'comment' must be specified.")
+	}
+
+	file, err := ioutil.TempFile("", "snap-commit-")
+	if err != nil {
+		return nil, fmt.Errorf("Can not create a temporary snap file.")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(commit.Sql)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Can not write the temporary snap file.")
+	}
+
+	args := []string{"commit"}
+	if commit.AllowDestructive {
+		args = append(args, "--allow-destructive")
+	}
+	args = append(args, databaseName, file.Name(), commit.Comment)
+	return exec.Command(this.Executable, args...).CombinedOutput()
+}
+
+// Update a managed database to a revision by running the update command. A 
+// revision of zero updates to the latest revision.
+func (this *apiServer) update(databaseName string, body []byte) ([]byte, error) {
+	var update updateRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &update); err != nil {
+			return nil, fmt.Errorf("The request body is not valid Json: %s", err)
+		}
+	}
+	return exec.Command(this.Executable, "update", databaseName, formatUint(update.Revision)).CombinedOutput()
+}
+
+// Create the status of a managed database.
+func newStatusDocument(databaseName string) (output.Document, int, error) {
+	if !database.DatabaseIsManaged(databaseName) {
+		return nil, http.StatusNotFound, fmt.Errorf("Database '%s' is not currently being managed.", databaseName)
+	}
+	status   := database.GetDatabaseStatus(databaseName)
+	document := statusDocument{
+		Database: databaseName,
+		CurrentRevision: status.CurrentRevision,
+		HeadRevision: status.HeadRevision,
+		UpToDate: status.CurrentRevision == status.HeadRevision && status.PendingRevision == 0 && !status.Interrupted,
+		PendingRevision: status.PendingRevision,
+	}
+	if status.Interrupted {
+		document.InterruptedUpdate = &interruptedUpdateDocument{status.InterruptedRevision, status.InterruptedDirection, status.InterruptedStatement, status.InterruptedError}
+	}
+	return document, http.StatusOK, nil
+}
+
+// Create the log of a managed database filtered by the query parameters of a 
+// request, which match the options of the log command. The query parameters 
+// are validated before any log entries are read.
+func newApiLogDocument(databaseName string, request *http.Request) (output.Document, int, error) {
+	query   := request.URL.Query()
+	options := LogOptions{
+		Author: query.Get("author"),
+		Since: query.Get("since"),
+		Until: query.Get("until"),
+		Grep: query.Get("grep"),
+		Range: query.Get("revisions"),
+		Object: query.Get("object"),
+		Pickaxe: query.Get("S"),
+		Stat: query.Get("stat") == "true",
+		Patch: query.Get("patch") == "true",
+	}
+	if limit := query.Get("n"); limit != "" {
+		var err error
+		if options.Limit, err = strconv.ParseUint(limit, 10, 64); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("Limit '%s' is not a valid number.", limit)
+		}
+	}
+	filter, err := options.filter()
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if !database.DatabaseIsManaged(databaseName) {
+		return nil, http.StatusNotFound, fmt.Errorf("Database '%s' is not currently being managed.", databaseName)
+	}
+	return newLogDocument(databaseName, filter, options), http.StatusOK, nil
+}
+
+// Create the update and down SQL of a revision of a managed database.
+func newApiRevisionDocument(databaseName string, revisionString string) (output.Document, int, error) {
+	revision, status, err := parseApiRevision(databaseName, revisionString)
+	if err != nil {
+		return nil, status, err
+	}
+	return revisionDocument{databaseName, revision, database.GetUpdateSql(databaseName, revision), database.GetDownSql(databaseName, revision)}, http.StatusOK, nil
+}
+
+// Create the full schema of a managed database at a revision. If no revision 
+// is passed the latest revision is used.
+func newApiSchemaDocument(databaseName string, revisionString string) (output.Document, int, error) {
+	if revisionString == "" {
+		revisionString = "head"
+	}
+	revision, status, err := parseApiRevision(databaseName, revisionString)
+	if err != nil {
+		return nil, status, err
+	}
+	return schemaDocument{databaseName, revision, database.GetSchema(databaseName, revision)}, http.StatusOK, nil
+}
+
+// Create a diff between two revisions of a managed database. The revisions are 
+// passed as for the diff command, if no to revision is passed the latest 
+// revision is used.
+func newApiDiffDocument(databaseName string, revisionString string) (output.Document, int, error) {
+	if !database.DatabaseIsManaged(databaseName) {
+		return nil, http.StatusNotFound, fmt.Errorf("Database '%s' is not currently being managed.", databaseName)
+	}
+	from, to, err := splitRevisions(revisionString)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	head := database.GetHeadRevision(databaseName)
+	if to == 0 {
+		to = head
+	}
+	if from > to {
+		return nil, http.StatusBadRequest, fmt.Errorf("'From' revision cannot be greater than to revision.")
+	}
+	if to > head {
+		return nil, http.StatusNotFound, fmt.Errorf("Database '%s' does not have a revision '%d'.", databaseName, to)
+	}
+	document, err := newDiffDocument(databaseName, from, to)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return document, http.StatusOK, nil
+}
+
+// Parse a revision of a managed database passed in a request. The revision can 
+// be 'head' for the latest revision. An error is returned along with the HTTP 
+// status to respond with if the database isn't managed or the revision doesn't 
+// exist.
+func parseApiRevision(databaseName string, revisionString string) (uint64, int, error) {
+	if !database.DatabaseIsManaged(databaseName) {
+		return 0, http.StatusNotFound, fmt.Errorf("Database '%s' is not currently being managed.", databaseName)
+	}
+	head := database.GetHeadRevision(databaseName)
+	if revisionString == "head" {
+		return head, http.StatusOK, nil
+	}
+	revision, err := strconv.ParseUint(revisionString, 10, 64)
+	if err != nil {
+		return 0, http.StatusBadRequest, fmt.Errorf("Revision '%s' is not specified correctly.", revisionString)
+	}
+	if revision == 0 || revision > head {
+		return 0, http.StatusNotFound, fmt.Errorf("Database '%s' does not have a revision '%d'.", databaseName, revision)
+	}
+	return revision, http.StatusOK, nil
+}
+
+// Write a document as the Json response to a request.
+func writeApiDocument(writer http.ResponseWriter, status int, document output.Document) {
+	bytes, err := output.EncodeJson(document)
+	if err != nil {
+		writeApiError(writer, http.StatusInternalServerError, "Can not encode the response.")
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	writer.Write(bytes)
+}
+
+// Write an error as the Json response to a request.
+func writeApiError(writer http.ResponseWriter, status int, format string, values ...interface{}) {
+	bytes, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, values...)})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	writer.Write(bytes)
+}
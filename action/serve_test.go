@@ -0,0 +1,237 @@
+package action
+
+// Imports.
+import "encoding/json"
+import "github.com/nomad-software/snap/output"
+import "io/ioutil"
+import "net/http"
+import "net/http/httptest"
+import "os"
+import "strings"
+import "testing"
+
+// The token used by servers accepting write requests in tests.
+const TEST_TOKEN string = "secret"
+
+// A request sent to the API server and the status it should be answered with.
+type apiCase struct {
+	Name string
+	Method string
+	Path string
+	Token string
+	Body string
+	Status int
+}
+
+// Send a request to a server and return the response.
+func serveRequest(server *apiServer, method string, path string, token string, body string) (*httptest.ResponseRecorder) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer " + token)
+	}
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// Decode the Json error of a response, failing the test if it isn't one.
+func decodeApiError(t *testing.T, name string, recorder *httptest.ResponseRecorder) (string) {
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("%s: expected a Json response, got '%s'", name, contentType)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body["error"] == "" || len(body) != 1 {
+		t.Errorf("%s: expected a Json error, got %q", name, recorder.Body.String())
+	}
+	return body["error"]
+}
+
+// Run requests against a server checking each is answered with the expected 
+// status and, for errors, a Json error.
+func runApiCases(t *testing.T, server *apiServer, cases []apiCase) {
+	for _, test := range cases {
+		recorder := serveRequest(server, test.Method, test.Path, test.Token, test.Body)
+		if recorder.Code != test.Status {
+			t.Errorf("%s: expected status %d, got %d: %s", test.Name, test.Status, recorder.Code, recorder.Body.String())
+		}
+		if recorder.Code != http.StatusOK {
+			decodeApiError(t, test.Name, recorder)
+		}
+	}
+}
+
+// Test requests outside the API's endpoints are not found.
+func TestServeUnknownEndpoints(t *testing.T) {
+	runApiCases(t, &apiServer{}, []apiCase{
+		{"root", http.MethodGet, "/", "", "", http.StatusNotFound},
+		{"prefix", http.MethodGet, "/v1", "", "", http.StatusNotFound},
+		{"unknown collection", http.MethodGet, "/v1/tables", "", "", http.StatusNotFound},
+		{"unknown endpoint", http.MethodGet, "/v1/databases/db/unknown", "", "", http.StatusNotFound},
+		{"missing revision", http.MethodGet, "/v1/databases/db/revisions", "", "", http.StatusNotFound},
+		{"too deep", http.MethodGet, "/v1/databases/db/revisions/1/sql", "", "", http.StatusNotFound},
+		{"other version", http.MethodGet, "/v2/databases", "", "", http.StatusNotFound},
+	})
+}
+
+// Test requests outside the API are passed to the web UI if it's enabled, while 
+// unknown endpoints inside the API are still not found.
+func TestServeRoutesToUi(t *testing.T) {
+	served := ""
+	server := &apiServer{Ui: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		served = request.URL.Path
+	})}
+
+	if recorder := serveRequest(server, http.MethodGet, "/index.html", "", ""); recorder.Code != http.StatusOK || served != "/index.html" {
+		t.Errorf("expected the web UI to serve '/index.html', got status %d serving '%s'", recorder.Code, served)
+	}
+
+	served = ""
+	if recorder := serveRequest(server, http.MethodGet, "/v1/unknown", "", ""); recorder.Code != http.StatusNotFound || served != "" {
+		t.Errorf("expected '/v1/unknown' not to be found, got status %d serving '%s'", recorder.Code, served)
+	}
+}
+
+// Test the OpenAPI description is served as valid Json.
+func TestServeOpenApi(t *testing.T) {
+	for _, path := range []string{"/v1/openapi.json", "/v1/openapi.json/"} {
+		recorder := serveRequest(&apiServer{}, http.MethodGet, path, "", "")
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusOK, recorder.Code)
+		}
+		if !json.Valid(recorder.Body.Bytes()) {
+			t.Errorf("%s: expected the OpenAPI description to be valid Json", path)
+		}
+	}
+}
+
+// Test read endpoints only accept GET requests and write endpoints only accept 
+// POST requests.
+func TestServeMethods(t *testing.T) {
+	runApiCases(t, &apiServer{Token: TEST_TOKEN}, []apiCase{
+		{"post openapi", http.MethodPost, "/v1/openapi.json", "", "", http.StatusMethodNotAllowed},
+		{"post list", http.MethodPost, "/v1/databases", "", "", http.StatusMethodNotAllowed},
+		{"post status", http.MethodPost, "/v1/databases/db/status", "", "", http.StatusMethodNotAllowed},
+		{"delete log", http.MethodDelete, "/v1/databases/db/log", "", "", http.StatusMethodNotAllowed},
+		{"put revision", http.MethodPut, "/v1/databases/db/revisions/1", "", "", http.StatusMethodNotAllowed},
+		{"post schema", http.MethodPost, "/v1/databases/db/schema", "", "", http.StatusMethodNotAllowed},
+		{"post diff", http.MethodPost, "/v1/databases/db/diff", "", "", http.StatusMethodNotAllowed},
+		{"get commit", http.MethodGet, "/v1/databases/db/commit", TEST_TOKEN, "", http.StatusMethodNotAllowed},
+		{"get update", http.MethodGet, "/v1/databases/db/update", TEST_TOKEN, "", http.StatusMethodNotAllowed},
+	})
+}
+
+// Test write requests are refused unless enabled and carrying the token. A 
+// request with the valid token gets as far as reading the database, which 
+// fails as no connection has been opened, without halting the server.
+func TestServeWriteToken(t *testing.T) {
+	runApiCases(t, &apiServer{}, []apiCase{
+		{"writes disabled", http.MethodPost, "/v1/databases/db/commit", TEST_TOKEN, "{}", http.StatusForbidden},
+	})
+	runApiCases(t, &apiServer{Token: TEST_TOKEN}, []apiCase{
+		{"missing token", http.MethodPost, "/v1/databases/db/commit", "", "{}", http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, "/v1/databases/db/update", "wrong", "{}", http.StatusUnauthorized},
+		{"token prefix", http.MethodPost, "/v1/databases/db/update", TEST_TOKEN[:3], "{}", http.StatusUnauthorized},
+		{"valid token", http.MethodPost, "/v1/databases/db/update", TEST_TOKEN, "{}", http.StatusInternalServerError},
+	})
+}
+
+// Test a request body larger than the limit is refused before the database is 
+// read.
+func TestServeMaxRequestSize(t *testing.T) {
+	runApiCases(t, &apiServer{Token: TEST_TOKEN}, []apiCase{
+		{"too large", http.MethodPost, "/v1/databases/db/commit", TEST_TOKEN, strings.Repeat("x", int(MAX_REQUEST_SIZE) + 1), http.StatusBadRequest},
+		{"largest", http.MethodPost, "/v1/databases/db/commit", TEST_TOKEN, strings.Repeat("x", int(MAX_REQUEST_SIZE)), http.StatusInternalServerError},
+	})
+}
+
+// Test a failure reading the database responds with an error instead of 
+// halting the server.
+func TestServeReadFailure(t *testing.T) {
+	recorder := serveRequest(&apiServer{}, http.MethodGet, "/v1/databases", "", "")
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+	decodeApiError(t, "read failure", recorder)
+}
+
+// Test invalid log query parameters are refused before the database is read.
+func TestApiLogDocumentValidation(t *testing.T) {
+	for _, query := range []string{"n=ten", "since=yesterday", "until=2015-13-01", "revisions=x", "revisions=1..2..3"} {
+		request := httptest.NewRequest(http.MethodGet, "/v1/databases/db/log?" + query, nil)
+		_, status, err := newApiLogDocument("db", request)
+		if status != http.StatusBadRequest || err == nil {
+			t.Errorf("%s: expected status %d and an error, got %d", query, http.StatusBadRequest, status)
+		}
+	}
+}
+
+// Test documents are written wrapped in their envelope.
+func TestWriteApiDocument(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeApiDocument(recorder, http.StatusOK, statusDocument{Database: "db", CurrentRevision: 2, HeadRevision: 3})
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected a Json response, got '%s'", contentType)
+	}
+
+	var envelope struct {
+		SchemaVersion int `json:"schemaVersion"`
+		Kind string `json:"kind"`
+		Data statusDocument `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("expected a Json document, got %q", recorder.Body.String())
+	}
+	if envelope.SchemaVersion != output.SCHEMA_VERSION || envelope.Kind != "status" {
+		t.Errorf("expected schema version %d of a status document, got version %d of a '%s' document", output.SCHEMA_VERSION, envelope.SchemaVersion, envelope.Kind)
+	}
+	if envelope.Data.Database != "db" || envelope.Data.CurrentRevision != 2 || envelope.Data.HeadRevision != 3 {
+		t.Errorf("expected the status of 'db' in the envelope, got %+v", envelope.Data)
+	}
+}
+
+// Test errors are written as a Json object with an error message.
+func TestWriteApiError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeApiError(recorder, http.StatusNotFound, "Database '%s' is not currently being managed.", "db")
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, recorder.Code)
+	}
+	if message := decodeApiError(t, "error", recorder); message != "Database 'db' is not currently being managed." {
+		t.Errorf("expected the formatted error message, got '%s'", message)
+	}
+}
+
+// Check no temporary files have been left in the temporary directory.
+func assertNoTempFiles(t *testing.T, name string) {
+	files, err := ioutil.ReadDir(os.TempDir())
+	if err != nil || len(files) > 0 {
+		t.Errorf("%s: expected no temporary files to be left, got %d", name, len(files))
+	}
+}
+
+// Test a diff is labelled with the passed names and its temporary files are 
+// removed.
+func TestDiffSchemas(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	diff, err := diffSchemas("revision-1", "SELECT 1;\n", "revision-2", "SELECT 2;\n")
+	if err != nil {
+		t.Fatalf("expected a diff, got %s", err)
+	}
+	if !strings.HasPrefix(diff, "--- revision-1\n+++ revision-2\n") || !strings.Contains(diff, "-SELECT 1;\n+SELECT 2;\n") {
+		t.Errorf("expected a unified diff between the revisions, got %q", diff)
+	}
+	assertNoTempFiles(t, "diff")
+}
+
+// Test a diff that can't be made is returned as an error, which the API 
+// responds to with a server error, instead of halting the server.
+func TestServeSurvivesDiffFailure(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("PATH", "")
+	if _, err := diffSchemas("revision-1", "SELECT 1;\n", "revision-2", "SELECT 2;\n"); err == nil {
+		t.Errorf("expected an error when the diff command can't be run")
+	}
+	assertNoTempFiles(t, "failed diff")
+}
@@ -3,10 +3,33 @@ package action
 // Imports.
 import "fmt"
 import "github.com/nomad-software/snap/database"
-import "log"
+import "github.com/nomad-software/snap/output"
+
+// A revision's SQL in machine readable output.
+type revisionDocument struct {
+	Database string `json:"database" yaml:"database"`
+	Revision uint64 `json:"revision" yaml:"revision"`
+	UpSql string `json:"upSql" yaml:"upSql"`
+	DownSql string `json:"downSql" yaml:"downSql"`
+}
+
+// Document interface implementation.
+func (this revisionDocument) Kind() (string) {
+	return "revision"
+}
+
+// Document interface implementation.
+func (this revisionDocument) CsvHeader() ([]string) {
+	return []string{"database", "revision", "upSql", "downSql"}
+}
+
+// Document interface implementation.
+func (this revisionDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Database, formatUint(this.Revision), this.UpSql, this.DownSql}}
+}
 
 // Show a managed database's update SQL at a particular revision.
-func ShowUpdateSql(databaseName string, revision uint64) {
+func ShowUpdateSql(databaseName string, revision uint64, format string) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
@@ -14,7 +37,7 @@ func ShowUpdateSql(databaseName string, revision uint64) {
 	head := database.GetHeadRevision(databaseName)
 
 	if revision > head {
-		log.Fatalf("Database '%s' does not have a revision '%d'.\n", databaseName, revision)
+		output.Fail(format, fmt.Errorf("Database '%s' does not have a revision '%d'.", databaseName, revision))
 	}
 
 	if revision <= 0 {
@@ -22,5 +45,11 @@ func ShowUpdateSql(databaseName string, revision uint64) {
 	}
 
 	sql := database.GetUpdateSql(databaseName, revision)
+
+	if !output.IsText(format) {
+		output.Write(format, revisionDocument{databaseName, revision, sql, database.GetDownSql(databaseName, revision)})
+		return
+	}
+
 	fmt.Println(sql)
 }
@@ -1,14 +1,43 @@
 package action
 
 // Imports.
+import "fmt"
 import "github.com/nomad-software/snap/database"
 import "log"
+import "os"
+import "os/exec"
+import "strings"
+import "text/tabwriter"
 
-// Show a managed database's full SQL at a particular revision.
-func UpdateSchemaToRevision(databaseName string, target uint64) {
+// Outcomes of updating a database in a separate process.
+const UPDATE_PENDING string = "pending"
+const UPDATE_SUCCEEDED string = "updated"
+const UPDATE_NOT_NEEDED string = "up to date"
+const UPDATE_FAILED string = "failed"
+const UPDATE_SKIPPED string = "skipped"
+
+// The outcome of updating a database in a separate process.
+type databaseUpdate struct {
+	Name string
+	From uint64
+	To uint64
+	Outcome string
+	Message string
+}
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this databaseUpdate) TabbedString() (string) {
+	return fmt.Sprintf("%s\t%d\t%d\t%s\t%s", this.Name, this.From, this.To, this.Outcome, this.Message)
+}
+
+// Update a managed database's schema to a particular revision. If backup is 
+// true any tables with data that could be lost are backed up first.
+func UpdateSchemaToRevision(databaseName string, target uint64, backup bool) {
 
 	database.AssertConfigDatabaseExists()
 	database.AssertDatabaseExists(databaseName)
+	database.AssertNoPendingRevision(databaseName)
+	database.AssertNoInterruptedUpdate(databaseName)
 
 	head    := database.GetHeadRevision(databaseName)
 	current := database.GetCurrentSchemaRevision(databaseName)
@@ -23,5 +52,52 @@ func UpdateSchemaToRevision(databaseName string, target uint64) {
 		log.Fatalf("Database '%s' is already at target revision '%d'.\n", databaseName, target)
 	}
 
+	database.SetBackupsEnabled(backup)
 	database.UpdateSchemaToRevision(databaseName, target)
 }
+
+// Resume an interrupted update of a managed database.
+func ResumeUpdate(databaseName string, skip bool, backup bool) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+	database.AssertNoPendingRevision(databaseName)
+
+	database.SetBackupsEnabled(backup)
+	if !database.ResumeUpdate(databaseName, skip) {
+		log.Fatalf("Database '%s' has no interrupted update to resume.\n", databaseName)
+	}
+
+	log.Println("Update resumed and completed successfully.")
+}
+
+// Update a single database by running snap's update command in a separate 
+// process. The outcome is returned along with the last line of output.
+func runUpdateProcess(executable string, databaseName string, target uint64, backup bool) (outcome string, message string) {
+	args := []string{"update"}
+	if !backup {
+		args = append(args, "--no-backup")
+	}
+	args = append(args, databaseName, fmt.Sprintf("%d", target))
+
+	output, err := exec.Command(executable, args...).CombinedOutput()
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	message = strings.TrimSpace(lines[len(lines) - 1])
+
+	if err != nil {
+		return UPDATE_FAILED, message
+	}
+	return UPDATE_SUCCEEDED, message
+}
+
+// Print the outcome of updating each database.
+func printDatabaseUpdates(updates []databaseUpdate) {
+	writer := tabwriter.NewWriter(os.Stdout, 8, 4, 1, ' ', 0)
+	fmt.Fprintln(writer, "Database\tFrom\tTo\tOutcome\tMessage")
+	fmt.Fprintln(writer, "--------\t----\t--\t-------\t-------")
+	for _, update := range updates {
+		fmt.Fprintln(writer, update.TabbedString())
+	}
+	writer.Flush()
+}
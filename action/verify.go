@@ -0,0 +1,22 @@
+package action
+
+// Imports.
+import "github.com/nomad-software/snap/database"
+import "log"
+
+// Verify the entire history of a managed database can be replayed.
+func VerifyHistory(databaseName string) {
+
+	database.AssertConfigDatabaseExists()
+	database.AssertDatabaseExists(databaseName)
+
+	log.Printf("Verifying the history of database '%s'.\n", databaseName)
+
+	revision, err := database.VerifyHistory(databaseName)
+	if err != nil {
+		log.Println(err)
+		log.Fatalf("Database '%s' history diverges at revision '%d'.\n", databaseName, revision)
+	}
+
+	log.Println("History verified successfully.")
+}
@@ -0,0 +1,35 @@
+package action
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/output"
+
+// Version information in machine readable output.
+type versionDocument struct {
+	Name string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// Document interface implementation.
+func (this versionDocument) Kind() (string) {
+	return "version"
+}
+
+// Document interface implementation.
+func (this versionDocument) CsvHeader() ([]string) {
+	return []string{"name", "version"}
+}
+
+// Document interface implementation.
+func (this versionDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Name, this.Version}}
+}
+
+// Show version information about the executable.
+func ShowVersion(name string, version string, format string) {
+	if !output.IsText(format) {
+		output.Write(format, versionDocument{name, version})
+		return
+	}
+	fmt.Println(name, version)
+}
@@ -0,0 +1,72 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Apply = cli.Command{
+	Name:        "apply",
+	Usage:       "[--dry-run] [--no-backup] <manifest>",
+	Description:
+`Move several managed databases to their target revisions as part of one 
+release. The databases and their target revisions are listed in a manifest 
+file written in Yaml or Json. Files with a '.json' extension are read as Json, 
+anything else is read as Yaml.
+
+The plan of which databases will move from and to which revisions is shown 
+first. The databases are then updated one at a time in dependency order, each 
+in a separate process. A database listing others under 'after' is only updated 
+once they have been. Otherwise databases are updated in the order listed. If an 
+update fails no more are started and the outcome for each database is shown.
+
+MANIFEST:
+    release: 2024-06
+    databases:
+      - database: auth
+        revision: 12
+      - database: orders
+        revision: 40
+        after: [auth]
+      - database: billing
+        revision: head
+        after: [orders]
+
+    The revision can be a revision number or 'head' for the latest
+    revision. If not specified it defaults to 'head'. The release name
+    is optional.
+
+ARGUMENTS:
+    manifest
+        The manifest file listing the databases to update.
+
+OPTIONS:
+    --dry-run
+        Show the plan without updating any databases.
+
+    --no-backup
+        Don't back up tables with data that could be lost.
+
+EXAMPLE:
+
+    snap apply release.yaml
+`,
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "dry-run", Usage: "Show the plan without updating any databases."},
+		cli.BoolFlag{Name: "no-backup", Usage: "Don't back up tables with data that could be lost."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) > 0 {
+			action.ApplyManifest(args.First(), ctx.Bool("dry-run"), !ctx.Bool("no-backup"))
+			return
+		}
+
+		log.Println("No manifest file specified.")
+		log.Fatalf("Run '%s help apply' for more information.\n", ctx.App.Name)
+	},
+}
@@ -0,0 +1,72 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var RestoreBackup = cli.Command{
+	Name:        "restore-backup",
+	Usage:       "[--remove|--remove-only] <database> [backup]",
+	Description:
+`Restore data backed up before destructive SQL was applied to a managed 
+database. Before a commit or an update applies SQL that could lose data, i.e. 
+SQL that drops or truncates tables or that drops or redefines columns, the 
+affected tables are copied into a backup database named after the managed 
+database and the revision, e.g. 'snap_backup_my_database_10'.
+
+If no backup is specified the backups of the database are listed. Otherwise 
+the rows of each table in the backup are copied back to the managed database. 
+Tables that no longer exist are recreated. Rows that already exist are updated 
+using the columns found in both the backup and the table, so data in columns 
+that were dropped and then added again is brought back. Columns that no longer 
+exist are not restored.
+
+ARGUMENTS:
+    database
+        The name of the managed database to restore data to.
+
+    backup (optional)
+        The name of the backup database to restore.
+
+OPTIONS:
+    --remove
+        Remove the backup after it has been restored.
+
+    --remove-only
+        Remove the backup without restoring it.
+
+EXAMPLE:
+
+    snap restore-backup my_database
+    snap restore-backup my_database snap_backup_my_database_10
+`,
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "remove", Usage: "Remove the backup after it has been restored."},
+		cli.BoolFlag{Name: "remove-only", Usage: "Remove the backup without restoring it."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) > 1 && ctx.Bool("remove-only") {
+			action.RemoveBackup(args.Get(0), args.Get(1))
+			return
+		}
+
+		if len(args) > 1 {
+			action.RestoreBackup(args.Get(0), args.Get(1), ctx.Bool("remove"))
+			return
+		}
+
+		if len(args) > 0 {
+			action.ListBackups(args.First())
+			return
+		}
+
+		log.Println("No database name specified.")
+		log.Fatalf("Run '%s help restore-backup' for more information.\n", ctx.App.Name)
+	},
+}
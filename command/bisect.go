@@ -0,0 +1,80 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Bisect = cli.Command{
+	Name:        "bisect",
+	Usage:       "--run <check> [options] <database> <good-revision>..<bad-revision>",
+	Description:
+`Find the first revision of a database that fails a check. A binary search is 
+made between a revision known to pass the check and a later one known to fail 
+it. At each step a temporary copy of the database is created at the revision 
+being tested and the check is run against it. Temporary copies are deleted 
+after each check.
+
+By default the check is a shell command which passes if it exits with a zero 
+status. The following environment variables are set for the command:
+
+    SNAP_DSN         A DSN of the temporary database,
+                     e.g. user:pass@tcp(localhost:3306)/snap_0A1B2C3D
+    SNAP_DATABASE    The name of the temporary database.
+    SNAP_REVISION    The revision being tested.
+    SNAP_HOST, SNAP_PORT, SNAP_USER, SNAP_PASSWORD
+                     The connection details of the server.
+
+If the --sql option is used the check is an SQL assertion run against the 
+temporary database. It passes if it runs without error and its first column 
+of its first row is not NULL, zero or empty. Statements that don't return 
+rows pass if they run without error.
+
+ARGUMENTS:
+    database
+        The name of the managed database.
+
+    good-revision
+        A revision that passes the check.
+
+    bad-revision
+        A later revision that fails the check.
+
+OPTIONS:
+    --run <check>
+        The shell command or SQL assertion used to check each revision.
+
+    --sql
+        Run the check as an SQL assertion instead of a shell command.
+
+EXAMPLE:
+
+    snap bisect --run "go test ./store/..." my_database 10..50
+    snap bisect --sql --run "SELECT COUNT(*) = 0 FROM users" my_database 10..50
+`,
+
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "run", Usage: "The shell command or SQL assertion used to check each revision."},
+		cli.BoolFlag{Name: "sql", Usage: "Run the check as an SQL assertion."},
+	},
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 1 {
+			if ctx.String("run") == "" {
+				log.Println("No check specified, use the --run option.")
+				log.Fatalf("Run '%s help bisect' for more information.\n", ctx.App.Name)
+			}
+			database       := args.Get(0)
+			revisionString := args.Get(1)
+			action.Bisect(database, revisionString, ctx.String("run"), ctx.Bool("sql"))
+			return
+		}
+
+		log.Println("Both a database and a range of revisions must be specified.")
+		log.Fatalf("Run '%s help bisect' for more information.\n", ctx.App.Name)
+	},
+}
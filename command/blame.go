@@ -0,0 +1,53 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+import "strconv"
+
+// Command.
+var Blame = cli.Command{
+	Name:        "blame",
+	Usage:       "<database> <table> [revision]",
+	Description:
+`Show the revision, author and date that last changed each line of a table's 
+definition. Each column, index and constraint is attributed to the revision in 
+which it last changed by comparing the schema stored for every revision.
+
+ARGUMENTS:
+    database
+        The name of the managed database.
+
+    table
+        The name of the table to show the definition of.
+
+    revision (optional)
+        The schema revision to show the definition at. This will default
+        to the latest schema revision if not specified.
+
+EXAMPLE:
+
+    snap blame my_database users
+`,
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 1 {
+			database := args.Get(0)
+			table    := args.Get(1)
+			// Ignore the error when getting the third argument because if the 
+			// argument can not be parsed to a uint64 then (along with the 
+			// error) zero is returned, which is what we want because we can 
+			// use it as an empty value.
+			revision, _ := strconv.ParseUint(args.Get(2), 10, 64)
+			action.ShowBlame(database, table, revision, ctx.GlobalString("format"))
+			return
+		}
+
+		log.Println("Both a database and a table must be specified.")
+		log.Fatalf("Run '%s help blame' for more information.\n", ctx.App.Name)
+	},
+}
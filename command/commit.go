@@ -9,7 +9,7 @@ import "log"
 var Commit = cli.Command{
 	Name:        "commit",
 	ShortName:   "ci",
-	Usage:       "<database> <snapfile> <message>",
+	Usage:       "[options] <database> <snapfile> <message>",
 	Description:
 `Commit a new schema revision to a managed database. A schema revision is 
 defined within a snap file which follows the format described below. This file 
@@ -43,14 +43,51 @@ Both comments must be on their own line. Here is a sample file:
     -- SNAP_DOWN
     DROP TABLE IF EXISTS foo;
 
+Before the snap file is committed it is checked for common problems. The file 
+is not committed if any errors are found. See 'snap help lint' for details.
+
 Once a commit is successful the snap file can be discarded as it is saved to 
 the snap configuration database.
 
+Before the snap file is committed it is validated by applying the up SQL and 
+then the down SQL to a temporary copy of the database. By default the copy 
+contains no data. To catch updates that fail against real data, such as adding 
+a NOT NULL column without a default or a unique index over duplicate values, 
+the copy can be seeded with a sample of rows from each table or with a fixture 
+file. When seeded, the up SQL is applied a second time after the down SQL and 
+the row count of each table after each step is shown, along with warnings of 
+any data lost.
+
+Any tables with data that could be lost by the up SQL, i.e. tables that are 
+dropped or truncated and tables with columns dropped or redefined, are backed 
+up before it is applied. See 'snap help restore-backup' for details.
+
+OPTIONS:
+    --allow-destructive
+        Allow tables and columns to be dropped in the up SQL.
+
+    --sample <rows>
+        Seed the validation database with up to this many rows copied from
+        each table of the database.
+
+    --fixture <file>
+        Seed the validation database by applying this SQL file.
+
+    --no-backup
+        Don't back up tables with data that could be lost.
+
 EXAMPLE:
 
     snap my_database changes.txt "Added table foo."
 	`,
 
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "allow-destructive", Usage: "Allow tables and columns to be dropped."},
+		cli.IntFlag{Name: "sample", Usage: "Seed the validation database with a sample of rows."},
+		cli.StringFlag{Name: "fixture", Usage: "Seed the validation database using a fixture file."},
+		cli.BoolFlag{Name: "no-backup", Usage: "Don't back up tables with data that could be lost."},
+	},
+
 	Action: func(ctx *cli.Context) {
 		args := ctx.Args()
 
@@ -58,7 +95,11 @@ EXAMPLE:
 			database := args.Get(0)
 			fileName := args.Get(1)
 			message  := args.Get(2)
-			action.CommitFile(database, fileName, message)
+			sample   := ctx.Int("sample")
+			if sample < 0 {
+				log.Fatalln("The number of sample rows can not be negative.")
+			}
+			action.CommitFile(database, fileName, message, ctx.Bool("allow-destructive"), uint64(sample), ctx.String("fixture"), !ctx.Bool("no-backup"))
 			return
 		}
 
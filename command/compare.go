@@ -0,0 +1,58 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Compare = cli.Command{
+	Name:        "compare",
+	Usage:       "<reference> <reference>",
+	Description:
+`Show an SQL diff between the schemas of two databases, which can be on 
+different servers, at different revisions or saved in dump files. The diff 
+will be in unified format and be written to stdout. Both schemas are normalised 
+using the rules configured for their database, as when committing, so only 
+real differences are shown.
+
+If the databases have different names, references to the second database in 
+its schema are renamed to the first.
+
+ARGUMENTS:
+    reference
+        The schema to compare, in one of the following formats:
+
+        database
+            The live schema of a database on the default server.
+
+        server:database
+            The live schema of a database on a server named in the
+            'servers' section of the config file.
+
+        database@revision
+            The stored schema of a managed database at a revision. The
+            revision can be 'head' for the latest revision.
+
+        file
+            A dump file, such as one written by 'snap dump'. A reference
+            is read as a file if a file with that name exists.
+
+EXAMPLE:
+
+    snap compare staging:orders orders
+    snap compare tenant_17 tenant_template@head
+`,
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) > 1 {
+			action.Compare(args.Get(0), args.Get(1))
+			return
+		}
+
+		log.Println("Two references must be specified.")
+		log.Fatalf("Run '%s help compare' for more information.\n", ctx.App.Name)
+	},
+}
@@ -38,7 +38,7 @@ EXAMPLE:
 		if len(args) > 1 {
 			database       := args.Get(0)
 			revisionString := args.Get(1)
-			action.Diff(database, revisionString)
+			action.Diff(database, revisionString, ctx.GlobalString("format"))
 			return
 		}
 
@@ -36,7 +36,7 @@ EXAMPLE:
 			// error) zero is returned, which is what we want because we can 
 			// use it as an empty value.
 			revision, _ := strconv.ParseUint(args.Get(1), 10, 64)
-			action.ShowFullSql(database, revision)
+			action.ShowFullSql(database, revision, ctx.GlobalString("format"))
 			return
 		}
 
@@ -0,0 +1,79 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Filter = cli.Command{
+	Name:        "filter",
+	Usage:       "<database> [[remove] include|exclude <pattern>]",
+	Description:
+`List, add or remove the object filters of a database. Object filters control 
+which tables, views, routines, triggers and events snap tracks. Objects that are 
+filtered out never appear in stored schemas, diffs or validation checks. This 
+is useful for tables managed by other tools, such as queue tables, session 
+tables or the temporary copies created by online migration tools.
+
+If any include filters exist, an object must match at least one of them to be 
+tracked. An object matching any exclude filter is never tracked. Triggers are 
+not tracked if the table they belong to is not tracked. Filters can be added 
+before a database is initialised.
+
+ARGUMENTS:
+    database
+        The name of the database to manage the filters of.
+
+    remove (optional)
+        Remove an existing filter instead of adding one.
+
+    include|exclude (optional)
+        The type of filter to add or remove. If not specified the current
+        filters are listed.
+
+    pattern (optional)
+        The pattern to match object names against. Patterns use shell glob
+        syntax where '*' matches any sequence of characters and '?' matches
+        a single character.
+
+EXAMPLE:
+
+    snap filter my_database exclude "_*_gho"
+    snap filter my_database remove exclude "_*_gho"
+`,
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) == 1 {
+			action.ListObjectFilters(args.First())
+			return
+		}
+
+		if len(args) == 3 {
+			databaseName := args.Get(0)
+			filterType   := args.Get(1)
+			pattern      := args.Get(2)
+			switch filterType {
+				case "include", "exclude":
+					action.AddObjectFilter(databaseName, filterType, pattern)
+					return
+			}
+		}
+
+		if len(args) == 4 && args.Get(1) == "remove" {
+			databaseName := args.Get(0)
+			filterType   := args.Get(2)
+			pattern      := args.Get(3)
+			switch filterType {
+				case "include", "exclude":
+					action.RemoveObjectFilter(databaseName, filterType, pattern)
+					return
+			}
+		}
+
+		log.Println("Arguments not specified correctly.")
+		log.Fatalf("Run '%s help filter' for more information.\n", ctx.App.Name)
+	},
+}
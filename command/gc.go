@@ -0,0 +1,63 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+import "time"
+
+// Command.
+var Gc = cli.Command{
+	Name:        "gc",
+	Usage:       "[--older-than <duration>] [--dry-run] [--force]",
+	Description:
+`Find and drop temporary databases left behind on the server. Snap creates 
+temporary databases named 'snap_' followed by eight hexadecimal characters to 
+validate commits and verify history. These are normally dropped when snap 
+finishes or is interrupted but may be left behind if snap is killed or loses 
+its connection to the server.
+
+Each temporary database is registered in the snap config database with the 
+owner, host and process that created it. A temporary database is in use, and 
+never dropped, if the process that created it is still running on this host. 
+Otherwise it is orphaned and dropped if it's older than the specified age.
+
+Whether a process is running can only be checked on this host. A temporary 
+database registered on another host, or not registered at all as it may 
+belong to snap using a different config database, is unconfirmed and only 
+dropped if forced. The age of an unregistered temporary database is taken 
+from its oldest table, if it has none it's never dropped.
+
+OPTIONS:
+    --older-than <duration>
+        Only drop temporary databases older than this, e.g. '30m' or
+        '48h'. The default is '24h'.
+
+    --dry-run
+        List the temporary databases without dropping any.
+
+    --force
+        Also drop unconfirmed temporary databases older than the
+        specified age. Make sure no snap is using them on another host
+        or with a different config database first.
+
+EXAMPLE:
+
+    snap gc --older-than 1h
+`,
+
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "older-than", Value: "24h", Usage: "Only drop temporary databases older than this."},
+		cli.BoolFlag{Name: "dry-run", Usage: "List temporary databases without dropping any."},
+		cli.BoolFlag{Name: "force", Usage: "Also drop unconfirmed temporary databases."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		olderThan, err := time.ParseDuration(ctx.String("older-than"))
+		if err != nil || olderThan < 0 {
+			log.Printf("Duration '%s' is not valid.\n", ctx.String("older-than"))
+			log.Fatalf("Run '%s help gc' for more information.\n", ctx.App.Name)
+		}
+		action.CollectGarbage(olderThan, ctx.Bool("dry-run"), ctx.Bool("force"))
+	},
+}
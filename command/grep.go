@@ -0,0 +1,59 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Grep = cli.Command{
+	Name:        "grep",
+	Usage:       "[options] <database> <pattern>",
+	Description:
+`Search the history of a database for lines of SQL matching a pattern. The up, 
+down and full SQL stored for each revision is searched and each matching line 
+is written as the revision, the section it was found in (up, down or full), its 
+line number and the line itself.
+
+ARGUMENTS:
+    database
+        The name of the managed database to search.
+
+    pattern
+        A regular expression to search for. The pattern is matched by
+        snap using the RE2 syntax of Go, not the REGEXP syntax of the
+        database server used by 'snap log --grep'.
+
+OPTIONS:
+    --revisions <from-revision>[..<to-revision>]
+        Only search this revision or the revisions in this range.
+
+    -i
+        Ignore case when matching the pattern.
+
+EXAMPLE:
+
+    snap grep my_database legacy_id
+    snap grep --revisions 10..20 -i my_database "drop column"
+`,
+
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "revisions", Usage: "Only search this revision or range of revisions."},
+		cli.BoolFlag{Name: "i", Usage: "Ignore case when matching the pattern."},
+	},
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 1 {
+			database := args.Get(0)
+			pattern  := args.Get(1)
+			action.Grep(database, pattern, ctx.String("revisions"), ctx.Bool("i"), ctx.GlobalString("format"))
+			return
+		}
+
+		log.Println("Both a database and a pattern must be specified.")
+		log.Fatalf("Run '%s help grep' for more information.\n", ctx.App.Name)
+	},
+}
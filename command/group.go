@@ -0,0 +1,94 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+import "strconv"
+
+// Command.
+var Group = cli.Command{
+	Name:        "group",
+	Usage:       "[<group> [create|add|remove <database> [revision]|delete]]",
+	Description:
+`List, create or delete groups of databases and add or remove their members. 
+A group is a set of databases with the same schema, such as per-tenant 
+databases, that share one history. Each database in a group has its own 
+current schema revision.
+
+A group is created from a managed database whose history it shares. Changes 
+are committed to that database as usual and the other databases in the group 
+are brought up to date using 'snap update --group'. Databases added to a group 
+become managed by snap but can't be committed to directly.
+
+When a database is added to a group, the revision of the group's history 
+matching its schema is found and recorded. An empty database is recorded at 
+revision zero so updating it creates the schema from scratch. If the schema 
+doesn't match any revision the revision must be specified.
+
+ARGUMENTS:
+    group (optional)
+        The name of the group. If not specified all groups are listed.
+        If no operation is specified the databases in the group are
+        listed.
+
+    create|add|remove|delete (optional)
+        Create the group from a managed database, add a database to the
+        group, remove a database from the group or delete the group.
+        Only empty groups can be deleted.
+
+    database (optional)
+        The database to create the group from, add or remove.
+
+    revision (optional)
+        The revision of the group's history the added database is at.
+
+EXAMPLE:
+
+    snap group tenants create tenant_template
+    snap group tenants add tenant_42
+`,
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) == 0 {
+			action.ListGroups()
+			return
+		}
+
+		if len(args) == 1 {
+			action.ListGroupMembers(args.First())
+			return
+		}
+
+		group     := args.Get(0)
+		operation := args.Get(1)
+
+		if operation == "delete" {
+			action.DeleteGroup(group)
+			return
+		}
+
+		if len(args) > 2 {
+			databaseName := args.Get(2)
+			switch operation {
+				case "create":
+					action.CreateGroup(group, databaseName)
+					return
+				case "add":
+					// Ignore the parse error as zero means the revision should 
+					// be detected.
+					revision, _ := strconv.ParseUint(args.Get(3), 10, 64)
+					action.AddGroupMember(group, databaseName, revision)
+					return
+				case "remove":
+					action.RemoveGroupMember(group, databaseName)
+					return
+			}
+		}
+
+		log.Println("Arguments not specified correctly.")
+		log.Fatalf("Run '%s help group' for more information.\n", ctx.App.Name)
+	},
+}
@@ -0,0 +1,71 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Lint = cli.Command{
+	Name:        "lint",
+	Usage:       "[--allow-destructive] <snapfile> [database]",
+	Description:
+`Check a snap file for common problems. Each problem found is reported as a 
+warning or an error along with the line it was found on. Snap files are linted 
+automatically when committed and are not committed if any errors are found.
+
+The following rules are checked:
+
+    destructive
+        A table or column is dropped in the up SQL.
+
+    downIfExists
+        An object is dropped in the down SQL without using IF EXISTS.
+
+    mixedDml
+        Data is modified in the same section as schema changes.
+
+    copyAlgorithm
+        A table containing data is altered using ALGORITHM=COPY. This can
+        only be checked if a database is specified.
+
+    primaryKey
+        A new table is created without a primary key.
+
+The severity of each rule can be configured per database in the config file.
+
+ARGUMENTS:
+    snapfile
+        The file to check. See 'snap help commit' for the file format.
+
+    database (optional)
+        The database the file will be committed to. This is used to find
+        the configured rules and to check the data affected by the file.
+
+OPTIONS:
+    --allow-destructive
+        Don't report tables or columns dropped in the up SQL.
+
+EXAMPLE:
+
+    snap lint changes.txt my_database
+`,
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "allow-destructive", Usage: "Allow tables and columns to be dropped."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) > 0 {
+			fileName := args.Get(0)
+			database := args.Get(1)
+			action.LintFile(fileName, database, ctx.Bool("allow-destructive"))
+			return
+		}
+
+		log.Println("No snap file specified.")
+		log.Fatalf("Run '%s help lint' for more information.\n", ctx.App.Name)
+	},
+}
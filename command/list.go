@@ -18,6 +18,6 @@ EXAMPLE:
 `,
 
 	Action: func(ctx *cli.Context) {
-		action.ListManagedDatabases()
+		action.ListManagedDatabases(ctx.GlobalString("format"))
 	},
 }
@@ -8,25 +8,105 @@ import "log"
 // Command.
 var Log = cli.Command{
 	Name:        "log",
-	Usage:       "<database>",
+	Usage:       "[options] <database> [<from-revision>[..<to-revision>]]",
 	Description:
-`Display a log of all schema update commits.
+`Display a log of all schema update commits, newest first. The log can be 
+filtered by author, date, comment and revision. All filters are applied by the 
+database so only the matching commits are read.
 
 ARGUMENTS:
     database
         The name of the managed database to list commits for.
 
+    from-revision (optional)
+        Only show this revision. If a to-revision is also specified, only
+        show revisions from this one.
+
+    to-revision (optional)
+        Only show revisions up to and including this one.
+
+OPTIONS:
+    --author <author>
+        Only show commits whose author contains this text.
+
+    --since <date>
+        Only show commits made on or after this date. Dates are in the
+        format 'YYYY-MM-DD' or 'YYYY-MM-DD hh:mm:ss'.
+
+    --until <date>
+        Only show commits made on or before this date.
+
+    --grep <pattern>
+        Only show commits whose comment matches this regular expression.
+        The pattern is matched by the database server using its REGEXP
+        syntax, not the syntax of the grep command.
+
+    --object <name>
+        Only show commits that changed the table, routine, trigger, view
+        or event of this name.
+
+    -S <string>
+        Only show commits that changed the number of times this string
+        occurs in the full schema, such as the commit where a column was
+        added or dropped.
+
+    -n <limit>
+        Only show this many of the newest matching commits.
+
+    --oneline
+        Show each commit on one line, as the revision followed by the
+        first line of the comment.
+
+    --stat
+        Show the objects changed by each commit.
+
+    -p
+        Show the update SQL of each commit.
+
 EXAMPLE:
 
     snap log my_database
+    snap log --author gary --since 2015-01-01 --oneline my_database 10..20
+    snap log --object users my_database
+    snap log -S legacy_id my_database
 `,
 
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "author", Usage: "Only show commits by this author."},
+		cli.StringFlag{Name: "since", Usage: "Only show commits made on or after this date."},
+		cli.StringFlag{Name: "until", Usage: "Only show commits made on or before this date."},
+		cli.StringFlag{Name: "grep", Usage: "Only show commits whose comment matches this pattern."},
+		cli.StringFlag{Name: "object", Usage: "Only show commits that changed this object."},
+		cli.StringFlag{Name: "S", Usage: "Only show commits that changed the occurrences of this string."},
+		cli.IntFlag{Name: "n", Usage: "Only show this many commits."},
+		cli.BoolFlag{Name: "oneline", Usage: "Show each commit on one line."},
+		cli.BoolFlag{Name: "stat", Usage: "Show the objects changed by each commit."},
+		cli.BoolFlag{Name: "p", Usage: "Show the update SQL of each commit."},
+	},
+
 	Action: func(ctx *cli.Context) {
 
 		args := ctx.Args()
 
+		if ctx.Int("n") < 0 {
+			log.Fatalln("The number of commits to show can not be negative.")
+		}
+
 		if len(args) > 0 {
-			action.ShowLog(args.First())
+			options := action.LogOptions{
+				Author: ctx.String("author"),
+				Since: ctx.String("since"),
+				Until: ctx.String("until"),
+				Grep: ctx.String("grep"),
+				Range: args.Get(1),
+				Object: ctx.String("object"),
+				Pickaxe: ctx.String("S"),
+				Limit: uint64(ctx.Int("n")),
+				OneLine: ctx.Bool("oneline"),
+				Stat: ctx.Bool("stat"),
+				Patch: ctx.Bool("p"),
+			}
+			action.ShowLog(args.First(), options, ctx.GlobalString("format"))
 			return
 		}
 
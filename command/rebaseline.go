@@ -0,0 +1,47 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Rebaseline = cli.Command{
+	Name:        "rebaseline",
+	Usage:       "[options] <database>",
+	Description:
+`Collapse the history of a managed database into a new revision 1 holding its 
+current schema. The old revisions are kept in the archive. The database must 
+be at its latest revision and can't be a member of a group or hold the history 
+of a group with other members.
+
+ARGUMENTS:
+    database
+        The name of the managed database.
+
+OPTIONS:
+    --comment <comment>
+        The comment of the new revision, defaults to 'Database rebaselined.'
+
+EXAMPLE:
+
+    snap rebaseline my_database
+`,
+
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "comment", Value: "Database rebaselined.", Usage: "The comment of the new revision."},
+	},
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 0 {
+			action.RebaselineDatabase(args.First(), ctx.String("comment"))
+			return
+		}
+
+		log.Println("No database name specified.")
+		log.Fatalf("Run '%s help rebaseline' for more information.\n", ctx.App.Name)
+	},
+}
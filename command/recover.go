@@ -0,0 +1,70 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Recover = cli.Command{
+	Name:        "recover",
+	Usage:       "[--finalise|--rollback] <database>",
+	Description:
+`Recover a revision left pending by an interrupted commit. When committing, a 
+revision is recorded as pending before its update SQL is applied and marked as 
+complete afterwards. If snap is interrupted in between, the database schema may 
+have changed without the revision being completed. No further commits or 
+updates are allowed until the pending revision is recovered.
+
+The live schema of the database is compared against the schema of the previous 
+revision and the schema expected after applying the pending update SQL. If the 
+update SQL was never applied the pending revision is removed. If it was applied 
+the revision is finalised. If the update SQL was only partially applied the 
+database must be fixed by hand before running this command again.
+
+ARGUMENTS:
+    database
+        The name of the managed database to recover.
+
+OPTIONS:
+    --finalise
+        Finalise the pending revision. This is needed if the update SQL
+        doesn't change the schema, as it can't be determined if it was
+        applied.
+
+    --rollback
+        Roll back the pending revision. If the update SQL was applied the
+        down SQL is applied to reverse it.
+
+EXAMPLE:
+
+    snap recover my_database
+`,
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "finalise", Usage: "Finalise the pending revision."},
+		cli.BoolFlag{Name: "rollback", Usage: "Roll back the pending revision."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if ctx.Bool("finalise") && ctx.Bool("rollback") {
+			log.Fatalln("Only one of --finalise and --rollback can be specified.")
+		}
+
+		if len(args) > 0 {
+			mode := "auto"
+			if ctx.Bool("finalise") {
+				mode = "finalise"
+			} else if ctx.Bool("rollback") {
+				mode = "rollback"
+			}
+			action.RecoverPendingRevision(args.First(), mode)
+			return
+		}
+
+		log.Println("No database name specified.")
+		log.Fatalf("Run '%s help recover' for more information.\n", ctx.App.Name)
+	},
+}
@@ -0,0 +1,44 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Rename = cli.Command{
+	Name:        "rename",
+	Usage:       "<old-database> <new-database>",
+	Description:
+`Move the history of a managed database to a database it has been renamed to. 
+MySql can't rename databases so the new database must already exist, e.g. by 
+moving each table to it. References to the old name in the stored revisions 
+are changed to the new name.
+
+ARGUMENTS:
+    old-database
+        The name the managed database had.
+
+    new-database
+        The name the database has been renamed to.
+
+EXAMPLE:
+
+    snap rename my_database my_new_database
+`,
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 1 {
+			oldName := args.Get(0)
+			newName := args.Get(1)
+			action.RenameDatabase(oldName, newName)
+			return
+		}
+
+		log.Println("Both the old and new database names must be specified.")
+		log.Fatalf("Run '%s help rename' for more information.\n", ctx.App.Name)
+	},
+}
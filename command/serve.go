@@ -0,0 +1,60 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+
+// Command.
+var Serve = cli.Command{
+	Name:        "serve",
+	Usage:       "[options]",
+	Description:
+`Serve the history of managed databases as a Json API over HTTP, along with a 
+web UI for browsing it. Every response of the API is wrapped in the same 
+envelope as machine readable output written by the other commands. The 
+OpenAPI description of the API is served at /v1/openapi.json.
+
+The web UI is served at / and lists the managed databases, the timeline of 
+revisions of each, the full SQL at any revision and a side by side diff 
+between any two revisions.
+
+    GET  /v1/databases
+    GET  /v1/databases/<database>/status
+    GET  /v1/databases/<database>/log
+    GET  /v1/databases/<database>/revisions/<revision>
+    GET  /v1/databases/<database>/schema?revision=<revision>
+    GET  /v1/databases/<database>/diff?revisions=<from>[..<to>]
+    POST /v1/databases/<database>/commit
+    POST /v1/databases/<database>/update
+
+The log endpoint accepts the options of the log command as query parameters, 
+e.g. ?author=gary&n=10&stat=true. Write requests are disabled unless enabled 
+using the --allow-writes option, in which case they must carry the token held 
+in the SNAP_API_TOKEN environment variable as a bearer token.
+
+OPTIONS:
+    --listen <address>
+        The address to listen on, defaults to ':8080'.
+
+    --allow-writes
+        Accept commit and update requests carrying the token.
+
+    --no-ui
+        Only serve the API, not the web UI.
+
+EXAMPLE:
+
+    snap serve --listen :8080
+    SNAP_API_TOKEN=secret snap serve --allow-writes
+`,
+
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "listen", Value: ":8080", Usage: "The address to listen on."},
+		cli.BoolFlag{Name: "allow-writes", Usage: "Accept commit and update requests carrying the token."},
+		cli.BoolFlag{Name: "no-ui", Usage: "Only serve the API, not the web UI."},
+	},
+
+	Action: func(ctx *cli.Context) {
+		action.Serve(ctx.String("listen"), ctx.Bool("allow-writes"), !ctx.Bool("no-ui"))
+	},
+}
@@ -38,7 +38,7 @@ EXAMPLE:
 			// error) zero is returned, which is what we want because we can 
 			// use it as an empty value.
 			revision, _ := strconv.ParseUint(args.Get(1), 10, 64)
-			action.ShowUpdateSql(database, revision)
+			action.ShowUpdateSql(database, revision, ctx.GlobalString("format"))
 			return
 		}
 
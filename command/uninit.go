@@ -0,0 +1,49 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Uninit = cli.Command{
+	Name:        "uninit",
+	Usage:       "[options] <database>",
+	Description:
+`Stop managing a database. The database itself is left untouched but its 
+revisions, object filters and the records of its backups are removed from the 
+snap config database. Its revisions can be kept in the archive.
+
+A database holding the history of a group can only be uninitialised once the 
+group has no other members.
+
+ARGUMENTS:
+    database
+        The name of the managed database.
+
+OPTIONS:
+    --archive
+        Keep the revisions of the database in the archive.
+
+EXAMPLE:
+
+    snap uninit --archive my_database
+`,
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "archive", Usage: "Keep the revisions of the database in the archive."},
+	},
+
+	Action: func(ctx *cli.Context) {
+
+		args := ctx.Args()
+
+		if len(args) > 0 {
+			action.UninitialiseDatabase(args.First(), ctx.Bool("archive"))
+			return
+		}
+
+		log.Println("No database name specified.")
+		log.Fatalf("Run '%s help uninit' for more information.\n", ctx.App.Name)
+	},
+}
@@ -10,9 +10,20 @@ import "strconv"
 var Update = cli.Command{
 	Name:        "update",
 	ShortName:   "up",
-	Usage:       "<database> [revision]",
+	Usage:       "[options] <database> [revision] | --group <group> [revision]",
 	Description:
-`Update the database to a particular revision.
+`Update the database to a particular revision. Each statement of each revision 
+is applied and recorded separately. If a statement fails the update stops and 
+the failed statement is recorded as the point to resume from. Once the cause 
+has been fixed the update can be resumed using the --resume option.
+
+Before the SQL of a revision is applied, any tables with data that could be 
+lost, i.e. tables that are dropped or truncated and tables with columns dropped 
+or redefined, are backed up. See 'snap help restore-backup' for details.
+
+Every database in a group can be updated using the --group option. Each 
+database is updated in a separate process and a summary of the outcome for 
+each database is shown at the end. See 'snap help group' for details.
 
 ARGUMENTS:
     database
@@ -23,14 +34,60 @@ ARGUMENTS:
         The schema revision to update the specified database to. This
         will default to the latest schema revision if not specified.
 
+OPTIONS:
+    --resume
+        Resume an interrupted update from the statement that failed. The
+        update continues to the revision originally requested so the
+        revision argument is ignored.
+
+    --skip
+        When resuming, skip the statement that failed. This is useful if
+        the statement has been applied by hand.
+
+    --no-backup
+        Don't back up tables with data that could be lost.
+
+    --group <group>
+        Update every database in the group instead of a single database.
+
+    --parallel <count>
+        The number of databases in a group to update at the same time.
+        The default is 4.
+
+    --max-failures <count>
+        Stop starting updates of databases in a group once this many have
+        failed. Zero means never stop. The default is 1.
+
 EXAMPLE:
 
     snap update my_database 10
+    snap update --group tenants --parallel 8 10
 `,
 
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "resume", Usage: "Resume an interrupted update."},
+		cli.BoolFlag{Name: "skip", Usage: "Skip the failed statement when resuming."},
+		cli.BoolFlag{Name: "no-backup", Usage: "Don't back up tables with data that could be lost."},
+		cli.StringFlag{Name: "group", Usage: "Update every database in a group."},
+		cli.IntFlag{Name: "parallel", Value: 4, Usage: "The number of databases in a group to update at the same time."},
+		cli.IntFlag{Name: "max-failures", Value: 1, Usage: "Stop updating a group after this many failures."},
+	},
+
 	Action: func(ctx *cli.Context) {
 		args := ctx.Args()
 
+		if ctx.String("group") != "" {
+			// Ignore the parse error as zero means the latest schema revision.
+			revision, _ := strconv.ParseUint(args.Get(0), 10, 64)
+			action.UpdateGroup(ctx.String("group"), revision, ctx.Int("parallel"), ctx.Int("max-failures"), !ctx.Bool("no-backup"))
+			return
+		}
+
+		if len(args) > 0 && ctx.Bool("resume") {
+			action.ResumeUpdate(args.Get(0), ctx.Bool("skip"), !ctx.Bool("no-backup"))
+			return
+		}
+
 		if len(args) > 0 {
 			database := args.Get(0)
 			// Ignore the error when getting the second argument because if the 
@@ -38,7 +95,7 @@ EXAMPLE:
 			// error) zero is returned, which is what we want because we can 
 			// use it as an empty value.
 			revision, _ := strconv.ParseUint(args.Get(1), 10, 64)
-			action.UpdateSchemaToRevision(database, revision)
+			action.UpdateSchemaToRevision(database, revision, !ctx.Bool("no-backup"))
 			return
 		}
 
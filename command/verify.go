@@ -0,0 +1,44 @@
+package command
+
+// Imports.
+import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
+import "log"
+
+// Command.
+var Verify = cli.Command{
+	Name:        "verify",
+	Usage:       "<database>",
+	Description:
+`Verify the entire history of a managed database can still be replayed. A 
+temporary database is created from the full SQL of the first revision, then the 
+update SQL of every revision is applied in order. Afterwards the down SQL of 
+every revision is applied in reverse order. After each step the schema of the 
+temporary database is compared against the stored schema of the revision it 
+should match. The first revision that fails to apply or doesn't match is 
+reported.
+
+Stored revisions can stop replaying correctly when the database server is 
+upgraded or when the object filters or normalisation rules are changed.
+
+ARGUMENTS:
+    database
+        The name of the managed database to verify.
+
+EXAMPLE:
+
+    snap verify my_database
+`,
+
+	Action: func(ctx *cli.Context) {
+		args := ctx.Args()
+
+		if len(args) > 0 {
+			action.VerifyHistory(args.First())
+			return
+		}
+
+		log.Println("No database name specified.")
+		log.Fatalf("Run '%s help verify' for more information.\n", ctx.App.Name)
+	},
+}
@@ -1,8 +1,8 @@
 package command
 
 // Imports.
-import "fmt"
 import "github.com/codegangsta/cli"
+import "github.com/nomad-software/snap/action"
 
 // Command.
 var Version = cli.Command{
@@ -17,6 +17,6 @@ EXAMPLE:
 `,
 
 	Action: func(ctx *cli.Context) {
-		fmt.Println(ctx.App.Name, ctx.App.Version)
+		action.ShowVersion(ctx.App.Name, ctx.App.Version, ctx.GlobalString("format"))
 	},
 }
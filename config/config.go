@@ -11,6 +11,43 @@ import "log"
 const UP_SQL_START string = "-- SNAP_UP"
 const DOWN_SQL_START string = "-- SNAP_DOWN"
 
+// Names of the normalisation rules applied to generated schemas.
+const NORMALISE_AUTO_INCREMENT string = "autoIncrement"
+const NORMALISE_DEFINERS string = "definers"
+const NORMALISE_WHITESPACE string = "whitespace"
+const NORMALISE_PARTITIONS string = "partitions"
+const NORMALISE_COMMENTS string = "comments"
+
+// The normalisation rules used when a database doesn't override them.
+var defaultNormalisationRules = map[string]bool{
+	NORMALISE_AUTO_INCREMENT: true,
+	NORMALISE_DEFINERS: true,
+	NORMALISE_WHITESPACE: true,
+	NORMALISE_PARTITIONS: false,
+	NORMALISE_COMMENTS: false,
+}
+
+// Names of the lint rules applied to snap files.
+const LINT_DESTRUCTIVE string = "destructive"
+const LINT_DOWN_IF_EXISTS string = "downIfExists"
+const LINT_MIXED_DML string = "mixedDml"
+const LINT_COPY_ALGORITHM string = "copyAlgorithm"
+const LINT_PRIMARY_KEY string = "primaryKey"
+
+// Severities of the lint rules.
+const LINT_ERROR string = "error"
+const LINT_WARNING string = "warning"
+const LINT_OFF string = "off"
+
+// The lint rule severities used when a database doesn't override them.
+var defaultLintRules = map[string]string{
+	LINT_DESTRUCTIVE: LINT_ERROR,
+	LINT_DOWN_IF_EXISTS: LINT_WARNING,
+	LINT_MIXED_DML: LINT_WARNING,
+	LINT_COPY_ALGORITHM: LINT_ERROR,
+	LINT_PRIMARY_KEY: LINT_WARNING,
+}
+
 // Package config struct used as a cache.
 var config *Config
 var jsonInfo string = `The config file should be in the following Json format:
@@ -22,11 +59,45 @@ var jsonInfo string = `The config file should be in the following Json format:
         "password": "bar",
         "protocol": "tcp",
         "host": "localhost",
-        "port": "3306"
+        "port": "3306",
+        "configDatabase": "snap_config"
+    },
+    "servers": {
+        "staging": {
+            "user": "foo",
+            "password": "bar",
+            "host": "staging.example.com"
+        }
+    },
+    "databases": {
+        "my_database": {
+            "normalise": {
+                "autoIncrement": true,
+                "definers": true,
+                "whitespace": true,
+                "partitions": false,
+                "comments": false
+            },
+            "lint": {
+                "destructive": "error",
+                "downIfExists": "warning",
+                "mixedDml": "warning",
+                "copyAlgorithm": "error",
+                "primaryKey": "warning"
+            },
+            "deltaSnapshots": false
+        }
     }
 }
 
-The database protocol, host and port fields are optional and default to the values shown above.
+The database protocol, host, port and configDatabase fields are optional and default to the values
+shown above. The configDatabase field names the database snap keeps its history in.
+The servers section is optional and names other servers whose databases can be compared.
+Any field not specified defaults to the value of the database section.
+The databases section is optional and holds per database settings. Any normalisation rule not
+specified defaults to the value shown above. Lint rules can be set to "error", "warning" or "off"
+and also default to the values shown above. If deltaSnapshots is true the full schema stored for
+each revision is stored as the changes from the previous revision to save space.
 `
 
 // This struct holds the database configuration details.
@@ -36,6 +107,7 @@ type database struct {
 	Protocol string
 	Host string
 	Port string
+	ConfigDatabase string
 }
 
 // Format the Database struct into a valid DSN (data source name) string.
@@ -48,10 +120,86 @@ func (this database) DnsString() (string) {
 		this.Port)
 }
 
+// This struct holds the configuration details of a single managed database.
+type managedDatabase struct {
+	Normalise map[string]bool
+	Lint map[string]string
+	DeltaSnapshots bool
+}
+
 // This struct holds the main configuration details.
 type Config struct {
 	Identity string
 	Database database
+	Servers map[string]database
+	Databases map[string]managedDatabase
+}
+
+// Return a copy of the config using the named server instead of the default 
+// one. Any connection details not specified for the server are taken from the 
+// default. The second return value is false if the server isn't configured.
+func (this Config) Server(name string) (Config, bool) {
+	server, ok := this.Servers[name]
+	if !ok {
+		return this, false
+	}
+	if server.User == "" {
+		server.User     = this.Database.User
+		server.Password = this.Database.Password
+	}
+	if server.Protocol == "" {
+		server.Protocol = this.Database.Protocol
+	}
+	if server.Host == "" {
+		server.Host = this.Database.Host
+	}
+	if server.Port == "" {
+		server.Port = this.Database.Port
+	}
+	this.Database = server
+	return this, true
+}
+
+// Return the normalisation rules to apply to the generated schema of the named 
+// database. Rules not specified in the config file take their default value.
+func (this Config) NormalisationRules(databaseName string) (map[string]bool) {
+	rules := make(map[string]bool)
+	for name, enabled := range defaultNormalisationRules {
+		rules[name] = enabled
+	}
+	for name, enabled := range this.Databases[databaseName].Normalise {
+		if _, ok := defaultNormalisationRules[name]; !ok {
+			log.Fatalf("Unknown normalisation rule '%s' configured for database '%s'.\n", name, databaseName)
+		}
+		rules[name] = enabled
+	}
+	return rules
+}
+
+// Return the severity of each lint rule to apply to snap files committed to the 
+// named database. Rules not specified in the config file take their default 
+// severity.
+func (this Config) LintRules(databaseName string) (map[string]string) {
+	rules := make(map[string]string)
+	for name, severity := range defaultLintRules {
+		rules[name] = severity
+	}
+	for name, severity := range this.Databases[databaseName].Lint {
+		if _, ok := defaultLintRules[name]; !ok {
+			log.Fatalf("Unknown lint rule '%s' configured for database '%s'.\n", name, databaseName)
+		}
+		if severity != LINT_ERROR && severity != LINT_WARNING && severity != LINT_OFF {
+			log.Fatalf("Lint rule '%s' configured for database '%s' has an unknown severity '%s'.\n", name, databaseName, severity)
+		}
+		rules[name] = severity
+	}
+	return rules
+}
+
+// Check if the full schema stored for each revision of the named database is 
+// stored as the changes from the previous revision instead of in full.
+func (this Config) DeltaSnapshots(databaseName string) (bool) {
+	return this.Databases[databaseName].DeltaSnapshots
 }
 
 // Return a new Config struct initialised with default values.
@@ -61,6 +209,7 @@ func newConfig() (*Config) {
 			Protocol: "tcp",
 			Host: "127.0.0.1",
 			Port: "3306",
+			ConfigDatabase: "snap_config",
 		},
 	}
 }
@@ -1,7 +1,6 @@
 package database
 
 // Imports.
-import "crypto/rand"
 import "fmt"
 import "github.com/nomad-software/snap/config"
 import "github.com/nomad-software/snap/sanitise"
@@ -21,45 +20,57 @@ func AssertDatabaseExists(name string) {
 	}
 }
 
+// Check if a table in the passed database contains any rows. If the table 
+// doesn't exist it is treated as empty.
+func TableHasRows(database string, table string) (bool) {
+	row, err := QueryRowUnsafe("SELECT 1 FROM `%s`.`%s` LIMIT 1;", database, table)
+	return err == nil && len(row) > 0
+}
+
 // Check that a database is being managed.
 func databaseIsManaged(database string) (bool) {
-	AssertUseConfigDatabase()
-	query := `SELECT id.name
-		FROM initialisedDatabases AS id
+	query := configSql(`SELECT id.name
+		FROM snap_config.initialisedDatabases AS id
 		WHERE id.name = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 	row, err := QueryRow(query, database)
 	exitOnError(err, "Error occurred checking database '%s' is being managed.", database)
 	return len(row) != 0
 }
 
+// Check that a database is being managed without throwing a fatal error if it 
+// isn't, e.g. to validate a request before acting on it.
+func DatabaseIsManaged(database string) (bool) {
+	return databaseIsManaged(database)
+}
+
 // Assert that a database is being managed. If not throw a fatal error.
 func assertDatabaseIsManaged(database string) {
 	if !databaseIsManaged(database) {
-		log.Fatalf("Database '%s' is not currently being managed.\n", database)
+		halt("Database '%s' is not currently being managed.", database)
 	}
 }
 
 // Add a database to be managed.
 func InitialiseDatabase(database string) {
 
-	fullSql := GenerateSchema(database)
+	fullSql        := GenerateSchema(database)
+	encoding, data := newSnapshot(database, 1, fullSql)
 
-	AssertUseConfigDatabase()
 	StartTransaction()
 
-		query := `INSERT INTO initialisedDatabases
+		query := configSql(`INSERT INTO snap_config.initialisedDatabases
 			(name, currentSchemaRevision)
-			VALUES (?, 1);`
+			VALUES (?, 1);`)
 
 		insertId, err := InsertRow(query, database)
 		exitOnError(err, "Database '%s' is already being managed.", database)
 
-		query = `INSERT INTO revisions
-			(databaseId, revision, upSql, downSql, fullSql, comment, author)
-			VALUES (?, 1, NULL, NULL, ?, "Database initialised.", ?);`
+		query = configSql(`INSERT INTO snap_config.revisions
+			(databaseId, revision, upSql, downSql, fullSql, fullSqlEncoding, comment, author)
+			VALUES (?, 1, NULL, NULL, ?, ?, "Database initialised.", ?);`)
 
-		_, err = InsertRow(query, insertId, fullSql, config.GetConfig().Identity)
+		_, err = InsertRow(query, insertId, data, encoding, config.GetConfig().Identity)
 		exitOnError(err, "Database '%s' is already being managed.", database)
 
 	Commit()
@@ -70,6 +81,7 @@ type database struct {
 	Name string
 	Revision string
 	Date string
+	Current string
 }
 
 // A collection of initialised databases.
@@ -97,22 +109,21 @@ func (this databaseList) LengthOfLongestName() (maxLength int) {
 // List all managed databases.
 func GetManagedDatabaseList() (list databaseList) {
 
-	AssertUseConfigDatabase()
-
-	query := `SELECT id.name,
+	query := configSql(`SELECT id.name,
 		MAX(r.revision) AS revision,
-		id.dateInitialised
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
-		GROUP BY r.databaseId
-		ORDER BY id.dateInitialised ASC;`
+		id.dateInitialised,
+		id.currentSchemaRevision
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
+		GROUP BY id.id
+		ORDER BY id.dateInitialised ASC;`)
 
 	rows, err := Query(query)
 	exitOnError(err, "Can not retrieve list of managed databases.")
 
 	list = make([]database, 0)
 	for _, row := range rows {
-		list = append(list, database{row.Str(0), row.Str(1), row.Str(2)})
+		list = append(list, database{row.Str(0), row.Str(1), row.Str(2), row.Str(3)})
 	}
 	return;
 }
@@ -123,50 +134,164 @@ type logEntry struct {
 	Comment string
 	Author string
 	Date string
+	UpSql string
 }
 
 // A collection of log entries.
 type logEntries []logEntry
 
-// Get log entries for the passed database.
-func GetLogEntries(database string) (log logEntries) {
+// Filters restricting the log entries returned. Empty or zero fields don't 
+// restrict the entries. The since and until dates are inclusive. The object 
+// restricts the entries to revisions that changed a table, routine, trigger, 
+// view or event of that name. The pickaxe restricts the entries to revisions 
+// that changed the number of times a string occurs in the full schema.
+type LogFilter struct {
+	Author string
+	Since string
+	Until string
+	Grep string
+	Object string
+	Pickaxe string
+	From uint64
+	To uint64
+	Limit uint64
+	IncludeSql bool
+}
+
+// Get log entries for the passed database, newest first. The entries are 
+// filtered in the query where possible so long histories don't need to be read 
+// in full. The update SQL of each entry is only read if requested.
+func GetLogEntries(database string, filter LogFilter) (log logEntries) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
+	conditions := []string{"id.name = ?"}
+	params     := []interface{}{database}
+
+	if filter.Author != "" {
+		conditions = append(conditions, "r.author LIKE CONCAT('%', ?, '%')")
+		params     = append(params, filter.Author)
+	}
+	if filter.Since != "" {
+		conditions = append(conditions, "r.dateApplied >= ?")
+		params     = append(params, filter.Since)
+	}
+	if filter.Until != "" {
+		conditions = append(conditions, "r.dateApplied <= ?")
+		params     = append(params, filter.Until)
+	}
+	if filter.Grep != "" {
+		conditions = append(conditions, "r.comment REGEXP ?")
+		params     = append(params, filter.Grep)
+	}
+	if filter.From > 0 {
+		conditions = append(conditions, "r.revision >= ?")
+		params     = append(params, filter.From)
+	}
+	if filter.To > 0 {
+		conditions = append(conditions, "r.revision <= ?")
+		params     = append(params, filter.To)
+	}
+
+	// Finding the revisions that changed an object or the occurrences of the 
+	// pickaxe string needs their SQL, so in those cases the limit is applied 
+	// once they have been found.
+	filterSql := filter.Object != "" || filter.Pickaxe != ""
+
+	upSql := "''"
+	if filter.IncludeSql || filter.Object != "" {
+		upSql = "r.upSql"
+	}
+
+	limit := ""
+	if filter.Limit > 0 && !filterSql {
+		limit  = "LIMIT ?"
+		params = append(params, filter.Limit)
+	}
+
+	query := configSql(fmt.Sprintf(`SELECT
 		r.revision,
 		r.comment,
 		r.author,
-		r.dateApplied
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
-		WHERE id.name = ?
-		ORDER BY r.revision DESC;`
-
-	rows, err := Query(query, database)
+		r.dateApplied,
+		%s
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
+		WHERE %s
+		ORDER BY r.revision DESC
+		%s;`, upSql, strings.Join(conditions, "\n\t\tAND "), limit))
+
+	rows, err := Query(query, params...)
 	exitOnError(err, "Can not retrieve log entries for database '%s'.", database)
 
+	// The occurrences of the pickaxe string are compared with the previous 
+	// revision, so its schema is read too. The first revision is compared with 
+	// an empty schema.
+	var schemas map[uint64]string
+	if filter.Pickaxe != "" {
+		from := filter.From
+		if from > 1 {
+			from--
+		}
+		schemas = getSchemas(database, from, filter.To)
+	}
+
 	log = make([]logEntry, 0)
 	for _, row := range rows {
-		log = append(log, logEntry{row.Str(0), row.Str(1), row.Str(2), row.Str(3)})
+		if filterSql && filter.Limit > 0 && uint64(len(log)) >= filter.Limit {
+			break
+		}
+		entry    := logEntry{row.Str(0), row.Str(1), row.Str(2), row.Str(3), row.Str(4)}
+		revision := row.Uint64(0)
+
+		// The first revision has no update SQL so its full SQL is used.
+		if row[4] == nil {
+			entry.UpSql = GetSchema(database, revision)
+		}
+		if filter.Object != "" && !revisionChangesObject(entry.UpSql, filter.Object) {
+			continue
+		}
+		if filter.Pickaxe != "" && strings.Count(schemas[revision], filter.Pickaxe) == strings.Count(schemas[revision - 1], filter.Pickaxe) {
+			continue
+		}
+		if !filter.IncludeSql {
+			entry.UpSql = ""
+		}
+		log = append(log, entry)
 	}
 	return;
 }
 
+// Check that a pattern is a valid regular expression for the server. Log 
+// entries are filtered using REGEXP, whose syntax depends on the server and 
+// differs from the regular expressions of Go, so the server is asked.
+func CheckRegexp(pattern string) (error) {
+	_, err := QueryRow("SELECT '' REGEXP ?;", pattern)
+	return err
+}
+
+// Check if the passed update SQL changes an object of the passed name.
+func revisionChangesObject(sql string, object string) (bool) {
+	for _, change := range sanitise.ObjectChanges(sql) {
+		if strings.EqualFold(change.Name, object) {
+			return true
+		}
+	}
+	return false
+}
+
 // Get the maximum revision of the passed database.
 func GetHeadRevision(database string) (uint64) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
+	query := configSql(`SELECT
 		MAX(r.revision)
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
 		WHERE id.name = ?
 		GROUP BY r.databaseId
-		LIMIT 1;`
+		LIMIT 1;`)
 
 	row, err := QueryRow(query, database)
 	exitOnError(err, "Can not retrieve latest revision for database '%s'.", database)
@@ -180,13 +305,12 @@ func GetHeadRevision(database string) (uint64) {
 func GetCurrentSchemaRevision(database string) (uint64) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
+	query := configSql(`SELECT
 		id.currentSchemaRevision
-		FROM initialisedDatabases AS id
+		FROM snap_config.initialisedDatabases AS id
 		WHERE id.name = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 
 	row, err := QueryRow(query, database)
 	exitOnError(err, "Can not retrieve schema revision for database '%s'.", database)
@@ -197,12 +321,11 @@ func GetCurrentSchemaRevision(database string) (uint64) {
 // Set the current schema revision of the passed database.
 func setCurrentSchemaRevision(database string, revision uint64) {
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `UPDATE initialisedDatabases AS id
+	query := configSql(`UPDATE snap_config.initialisedDatabases AS id
 		SET id.currentSchemaRevision = ?
 		WHERE id.name = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 
 	err := Exec(query, revision, database)
 	exitOnError(err, "Error occurred while setting the current schema revision for database '%s'.", database)
@@ -214,20 +337,21 @@ func setCurrentSchemaRevision(database string, revision uint64) {
 func GetUpdateSql(database string, revision uint64) (upSql string) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
-		COALESCE(r.upSql, r.fullSql)
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
+	query := configSql(`SELECT
+		r.upSql
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
 		WHERE id.name = ?
 		AND r.revision = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 
 	row, err := QueryRow(query, database, revision)
 	exitOnError(err, "Can not retrieve update SQL for database '%s' at revision '%d'.", database, revision)
 
-	if len(row) > 0 {
+	if len(row) > 0 && row[0] == nil {
+		upSql = GetSchema(database, revision)
+	} else if len(row) > 0 {
 		upSql = row.Str(0)
 	}
 	return
@@ -237,15 +361,14 @@ func GetUpdateSql(database string, revision uint64) (upSql string) {
 func GetDownSql(database string, revision uint64) (downSql string) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
+	query := configSql(`SELECT
 		r.downSql
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
 		WHERE id.name = ?
 		AND r.revision = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 
 	row, err := QueryRow(query, database, revision)
 	exitOnError(err, "Can not retrieve down SQL for database '%s' at revision '%d'.", database, revision)
@@ -256,25 +379,103 @@ func GetDownSql(database string, revision uint64) (downSql string) {
 	return
 }
 
-// Return the full SQL for the database and revision passed.
+// Return the full SQL for the database and revision passed. A snapshot stored 
+// as a delta is rebuilt from the nearest full snapshot before it.
 func GetSchema(database string, revision uint64) (sql string) {
+	return getSchemas(database, revision, revision)[revision]
+}
+
+// A snapshot of the full schema of a database at a revision.
+type schemaSnapshot struct {
+	Revision uint64
+	Author string
+	Date string
+	FullSql string
+}
+
+// A collection of schema snapshots.
+type schemaSnapshotList []schemaSnapshot
+
+// Get the full schema of the passed database at every revision, oldest first.
+func GetSchemaHistory(database string) (list schemaSnapshotList) {
 
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
 
-	query := `SELECT
-		r.fullSql
-		FROM initialisedDatabases AS id
-		INNER JOIN revisions AS r ON r.databaseId = id.id
+	query := configSql(`SELECT
+		r.revision,
+		r.author,
+		r.dateApplied
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
 		WHERE id.name = ?
-		AND r.revision = ?
-		LIMIT 1;`
+		ORDER BY r.revision ASC;`)
 
-	row, err := QueryRow(query, database, revision)
-	exitOnError(err, "Can not retrieve full SQL for database '%s' at revision '%d'.", database, revision)
+	rows, err := Query(query, database)
+	exitOnError(err, "Can not retrieve the schema history of database '%s'.", database)
 
-	if len(row) > 0 {
-		sql = row.Str(0)
+	schemas := getSchemas(database, 0, 0)
+
+	list = make(schemaSnapshotList, 0)
+	for _, row := range rows {
+		list = append(list, schemaSnapshot{row.Uint64(0), row.Str(1), row.Str(2), schemas[row.Uint64(0)]})
+	}
+	return
+}
+
+// The SQL stored for a revision.
+type revisionSql struct {
+	Revision uint64
+	UpSql string
+	DownSql string
+	FullSql string
+}
+
+// A collection of the SQL stored for revisions.
+type revisionSqlList []revisionSql
+
+// Get the SQL stored for the revisions of the passed database between two 
+// revisions inclusive, oldest first. A zero revision leaves that end of the 
+// range open. If text is passed only revisions whose SQL contains it are 
+// returned, ignoring case. Full SQL snapshots are compressed so they are 
+// searched once they have been read.
+func SearchRevisionSql(database string, from uint64, to uint64, text string) (list revisionSqlList) {
+
+	assertDatabaseIsManaged(database)
+
+	conditions := []string{"id.name = ?"}
+	params     := []interface{}{database}
+
+	if from > 0 {
+		conditions = append(conditions, "r.revision >= ?")
+		params     = append(params, from)
+	}
+	if to > 0 {
+		conditions = append(conditions, "r.revision <= ?")
+		params     = append(params, to)
+	}
+
+	query := configSql(fmt.Sprintf(`SELECT
+		r.revision,
+		r.upSql,
+		r.downSql
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
+		WHERE %s
+		ORDER BY r.revision ASC;`, strings.Join(conditions, "\n\t\tAND ")))
+
+	rows, err := Query(query, params...)
+	exitOnError(err, "Can not search the revisions of database '%s'.", database)
+
+	schemas := getSchemas(database, from, to)
+	text     = strings.ToLower(text)
+
+	list = make(revisionSqlList, 0)
+	for _, row := range rows {
+		revision := revisionSql{row.Uint64(0), row.Str(1), row.Str(2), schemas[row.Uint64(0)]}
+		if !strings.Contains(strings.ToLower(revision.UpSql + "\n" + revision.DownSql + "\n" + revision.FullSql), text) {
+			continue
+		}
+		list = append(list, revision)
 	}
 	return
 }
@@ -292,77 +493,117 @@ func CopyDatabase(source string, destination string, revision uint64) {
 
 	sql := GetSchema(source, revision)
 	sql  = sanitise.SanitiseSql(sql)
+	sql  = retargetDatabaseReferences(sql, source, destination)
 
 	assertUseDatabase(destination)
 	err = ExecMulti(sql)
 	exitOnError(err, "Can not copy schema to new database '%s'.", destination)
 }
 
-// Validate that the schema file updates then correctly reverses any changes made.
-func ValidateSchemaUpdate(database string, file string) {
+// Replace all fully qualified references to the source database with the 
+// destination database. Views are always stored by MySql with fully qualified 
+// references so without this a copied view would still select from the source.
+func retargetDatabaseReferences(sql string, source string, destination string) (string) {
+	return strings.Replace(sql, fmt.Sprintf("`%s`.", source), fmt.Sprintf("`%s`.", destination), -1)
+}
+
+// Generate the schema of a copy of a managed database as if it were generated 
+// from the managed database itself. The copy may be a temporary database or a 
+// member of a group, so only references to it as a database are changed.
+func generateComparableSchema(source string, database string, filters objectFilters) (string) {
+	sql := generateRawSchema(source, filters)
+	sql  = retargetDatabaseReferences(sql, source, database)
+	sql  = strings.Replace(sql, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` ", source), fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` ", database), 1)
+	sql  = strings.Replace(sql, fmt.Sprintf("USE `%s`;", source), fmt.Sprintf("USE `%s`;", database), 1)
+	return NormaliseSchema(database, sql)
+}
+
+// Validate that the schema file updates then correctly reverses any changes 
+// made. If seed options are passed the temporary database is seeded with data 
+// before the file is applied and the update SQL is applied again after it has 
+// been reversed. The row counts of each table after each step are returned.
+func ValidateSchemaUpdate(database string, file string, seed SeedOptions) (counts tableRowCountList) {
 	assertDatabaseIsManaged(database)
 
 	temp     := generateTempDatabaseName()
 	revision := GetHeadRevision(database)
 	CopyDatabase(database, temp, revision)
 
+	// The structure before the file is applied is generated from the copy 
+	// rather than taken from the stored revision. This means both structures 
+	// are generated using the current object filters and normalisation rules 
+	// even if they have changed since the revision was stored.
+	filters          := GetObjectFilters(database)
+	currentStructure := generateComparableSchema(temp, database, filters)
+
 	sql := sanitise.ReadFile(file)
 	sql  = sanitise.SanitiseSql(sql)
+	upSql, downSql := splitSqlFile(sql)
 
-	assertUseDatabase(temp)
-	err := ExecMulti(sql)
-	exitOnError(err, "Error occurred applying file to current schema.")
-
-	currentStructure := GetSchema(database, revision)
-	updatedStructure := GenerateSchema(temp)
-	updatedStructure  = strings.Replace(updatedStructure, temp, database, -1)
+	seedTempDatabase(temp, database, seed, filters)
+	counts = newTableRowCountList()
+	counts.record(SEEDED_STEP, countRows(temp, filters))
+
+	err := applySqlToTempDatabase(temp, database, upSql)
+	exitOnError(err, "Error occurred applying update SQL to current schema.")
+	counts.record(UP_STEP, countRows(temp, filters))
+	updatedStructure := generateComparableSchema(temp, database, filters)
+
+	err = applySqlToTempDatabase(temp, database, downSql)
+	exitOnError(err, "Error occurred applying down SQL to updated schema.")
+	counts.record(DOWN_STEP, countRows(temp, filters))
+	reversedStructure := generateComparableSchema(temp, database, filters)
+
+	if seed.Enabled() {
+		err = applySqlToTempDatabase(temp, database, upSql)
+		exitOnError(err, "Error occurred applying update SQL again to reversed schema.")
+		counts.record(REAPPLIED_STEP, countRows(temp, filters))
+		if generateComparableSchema(temp, database, filters) != updatedStructure {
+			deleteTempDatabases()
+			log.Fatalln("File not commited because its updates can't be correctly applied again after being reversed.")
+		}
+	}
 
 	deleteTempDatabases()
 
-	if currentStructure != updatedStructure {
+	if currentStructure != reversedStructure {
 		log.Fatalln("File not commited because it doesn't correctly reverse any contained updates.")
 	}
+	return
 }
 
 // Create a new revision for a managed database. This function applies the file 
-// and creates the new revision in the database.
+// and creates the new revision in the database. Because MySql implicitly 
+// commits any DDL, the revision is first recorded as pending and only marked 
+// complete once the update SQL has been applied. If snap is interrupted in 
+// between, the pending revision is left for the recover command to resolve.
 func CreateNewRevision(database string, file string, comment string) {
 	assertDatabaseIsManaged(database)
+	AssertNoPendingRevision(database)
 
 	sql := sanitise.ReadFile(file)
 	sql  = sanitise.SanitiseSql(sql)
 
-	databaseId     := getDatabaseId(database)
 	revision       := GetHeadRevision(database) + 1
 	upSql, downSql := splitSqlFile(sql)
 	author         := config.GetConfig().Identity
 
-	StartTransaction()
-
-		applyUpdateToDatabase(database, upSql)
-		fullSql := GenerateSchema(database)
-
-		AssertUseConfigDatabase()
-		query := `INSERT INTO revisions
-			(databaseId, revision, upSql, downSql, fullSql, comment, author)
-			VALUES (?, ?, ?, ?, ?, ?, ?);`
+	id := createPendingRevision(database, revision, upSql, downSql, comment, author)
 
-		_, err := InsertRow(query, databaseId, revision, upSql, downSql, fullSql, comment, author)
-		exitOnError(err, "Error occurred while creating a new revision for database '%s'.", database)
-
-		setCurrentSchemaRevision(database, revision)
+	backupDestructiveTables(database, revision, UP_DIRECTION, upSql)
+	applyUpdateToDatabase(database, upSql)
+	fullSql := GenerateSchema(database)
 
-	Commit()
+	completePendingRevision(database, id, revision, fullSql)
 }
 
 // Get the id of a managed database.
 func getDatabaseId(database string) (uint64) {
 	assertDatabaseIsManaged(database)
-	AssertUseConfigDatabase()
-	query := `SELECT id.id
-		FROM initialisedDatabases AS id
+	query := configSql(`SELECT id.id
+		FROM snap_config.initialisedDatabases AS id
 		WHERE id.name = ?
-		LIMIT 1;`
+		LIMIT 1;`)
 	row, err := QueryRow(query, database)
 	exitOnError(err, "Error occurred while retrieving database '%s' id.", database)
 	return row.Uint64(0)
@@ -376,7 +617,7 @@ func applyUpdateToDatabase(database string, sql string) {
 	exitOnError(err, "Error occurred while modifying database '%s' schema.", database)
 }
 
-// Split the update SQL into the up and down sections.
+// Split the update SQL into the up and down sections. 
 // This function assumes the SQL has been validated before hand.
 func splitSqlFile(sql string) (upSql string, downSql string) {
 	upLines   := make([]string, 0)
@@ -390,6 +631,9 @@ func splitSqlFile(sql string) (upSql string, downSql string) {
 		} else if line == config.DOWN_SQL_START {
 			output = &downLines
 			continue
+		} else if output == nil {
+			// Ignore anything before the first section.
+			continue
 		}
 		*output = append(*output, line)
 	}
@@ -398,53 +642,48 @@ func splitSqlFile(sql string) (upSql string, downSql string) {
 	return
 }
 
-// Generate a random name for a temporary database.
-func generateTempDatabaseName() (string) {
-	bytes := make([]byte, 4)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		log.Fatalln("Error occurred generating a temporary database name.")
-	}
-	name := fmt.Sprintf("snap_%X", bytes)
-	// Record the name to drop later to clean up.
-	tempDatabases = append(tempDatabases, name)
-	return name;
-}
-
-// Update the schema of a managed database to a previously committed revision.
+// Update the schema of a managed database to a previously committed revision. 
+// Each statement of each revision is applied and recorded separately so an 
+// update that fails part way through can be resumed.
 func UpdateSchemaToRevision(database string, target uint64) {
 	assertDatabaseIsManaged(database)
 	revision := GetCurrentSchemaRevision(database)
 	if target > revision {
 		for revision < target {
 			revision++
-			forwardSchema(database, revision)
+			forwardSchema(database, target, revision, 0)
 		}
 	} else {
 		for revision > target {
-			reverseSchema(database, revision)
+			reverseSchema(database, target, revision, 0)
 			revision--
 		}
 	}
+	clearUpdateProgress(database)
 }
 
-// Foward the schema to a stored revision.
-func forwardSchema(database string, target uint64) {
+// Foward the schema to a stored revision, starting from the passed statement.
+func forwardSchema(database string, target uint64, revision uint64, statement uint64) {
 	assertDatabaseIsManaged(database)
-	sql := GetUpdateSql(database, target)
-	assertUseDatabase(database)
-	err := ExecMulti(sql)
-	exitOnError(err, "Error occurred applying update SQL to database '%s'.", database)
-	setCurrentSchemaRevision(database, target)
+	sql := retargetSharedHistory(database, GetUpdateSql(database, revision))
+	applyRevisionStatements(database, target, revision, UP_DIRECTION, sql, statement)
+	setCurrentSchemaRevision(database, revision)
 }
 
-// Reverse the schema to a stored revision.
-func reverseSchema(database string, target uint64) {
+// Retarget the SQL of a revision to a database that shares the history of 
+// another. The stored SQL references the history database so views created by 
+// it would otherwise select from there instead.
+func retargetSharedHistory(database string, sql string) (string) {
+	if history, shared := getSharedHistoryDatabase(database); shared {
+		return retargetDatabaseReferences(sql, history, database)
+	}
+	return sql
+}
+
+// Reverse the schema of a stored revision, starting from the passed statement.
+func reverseSchema(database string, target uint64, revision uint64, statement uint64) {
 	assertDatabaseIsManaged(database)
-	sql := GetDownSql(database, target)
-	assertUseDatabase(database)
-	err := ExecMulti(sql)
-	exitOnError(err, "Error occurred applying down SQL to database '%s'.", database)
-	target--
-	setCurrentSchemaRevision(database, target)
+	sql := retargetSharedHistory(database, GetDownSql(database, revision))
+	applyRevisionStatements(database, target, revision, DOWN_DIRECTION, sql, statement)
+	setCurrentSchemaRevision(database, revision - 1)
 }
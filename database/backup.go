@@ -0,0 +1,267 @@
+package database
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/sanitise"
+import "log"
+import "strings"
+
+// The prefix of the names of databases holding backups.
+const BACKUP_DATABASE_PREFIX string = "snap_backup_"
+
+// The maximum length of a database name allowed by MySql.
+const MAX_DATABASE_NAME_LENGTH int = 64
+
+// The SQL to create the backups table.
+const backupsTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.backups (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  databaseId INT UNSIGNED NOT NULL,
+  revision INT UNSIGNED NOT NULL,
+  direction ENUM('up', 'down') NOT NULL,
+  backupDatabase VARCHAR(64) NOT NULL,
+  tableNames TEXT NOT NULL COMMENT 'Comma separated names of the tables backed up.',
+  dateCreated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE INDEX uniqueBackupDatabase (backupDatabase ASC),
+  CONSTRAINT fk_backups_initialisedDatabases
+    FOREIGN KEY (databaseId)
+    REFERENCES snap_config.initialisedDatabases (id)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;`
+
+// Whether affected tables are backed up before destructive SQL is applied.
+var backupsEnabled bool = true
+
+// Enable or disable backing up affected tables before destructive SQL is 
+// applied to a managed database.
+func SetBackupsEnabled(enabled bool) {
+	backupsEnabled = enabled
+}
+
+// A backup of tables taken before destructive SQL was applied.
+type backup struct {
+	Database string
+	Revision string
+	Direction string
+	Tables string
+	Date string
+}
+
+// A collection of backups.
+type backupList []backup
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this backup) TabbedString() (string) {
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s", this.Database, this.Revision, this.Direction, this.Tables, this.Date)
+}
+
+// Return the names of the backed up tables.
+func (this backup) TableNames() ([]string) {
+	return strings.Split(this.Tables, ",")
+}
+
+// Back up any tables containing data that could be lost by applying SQL of a 
+// revision to a managed database. The tables are copied into a new database 
+// named after the managed database and the revision, which is recorded in the 
+// config database so the data can be restored later.
+func backupDestructiveTables(database string, revision uint64, direction string, sql string) {
+	if !backupsEnabled {
+		return
+	}
+
+	tables := make([]string, 0)
+	for _, table := range sanitise.DestructiveTables(sql) {
+		if TableHasRows(database, table) {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return
+	}
+
+	name               := generateBackupDatabaseName(database, revision)
+	charSet, collation := GetDatabaseEncoding(database)
+
+	err := createDatabase(name, charSet, collation)
+	exitOnError(err, "Can not create backup database '%s'.", name)
+
+	for _, table := range tables {
+		err = ExecUnsafe("CREATE TABLE `%s`.`%s` LIKE `%s`.`%s`;", name, table, database, table)
+		exitOnError(err, "Can not create backup of table '%s'.", table)
+
+		columnList := strings.Join(getInsertableColumns(database, table), ", ")
+		err = ExecUnsafe("INSERT INTO `%s`.`%s` (%s) SELECT %s FROM `%s`.`%s`;", name, table, columnList, columnList, database, table)
+		exitOnError(err, "Can not copy the rows of table '%s' to backup database '%s'.", table, name)
+	}
+
+	databaseId := getDatabaseId(database)
+
+	query := configSql(`INSERT INTO snap_config.backups
+		(databaseId, revision, direction, backupDatabase, tableNames)
+		VALUES (?, ?, ?, ?, ?);`)
+
+	err = Exec(query, databaseId, revision, direction, name, strings.Join(tables, ","))
+	exitOnError(err, "Error occurred while recording backup database '%s'.", name)
+
+	log.Printf("Backed up table(s) '%s' of database '%s' to '%s'.\n", strings.Join(tables, "', '"), database, name)
+}
+
+// Generate a unique name for a backup database. The name of the managed 
+// database is shortened if needed to keep the name within the length allowed 
+// and a number is appended if a backup of the same revision already exists.
+func generateBackupDatabaseName(database string, revision uint64) (string) {
+	suffix := fmt.Sprintf("_%d", revision)
+	for count := 1; ; count++ {
+		if count > 1 {
+			suffix = fmt.Sprintf("_%d_%d", revision, count)
+		}
+		available := MAX_DATABASE_NAME_LENGTH - len(BACKUP_DATABASE_PREFIX) - len(suffix)
+		name      := database
+		if len(name) > available {
+			name = name[:available]
+		}
+		name = BACKUP_DATABASE_PREFIX + name + suffix
+		if !DatabaseExists(name) {
+			return name
+		}
+	}
+}
+
+// Return the names of the columns of a table that can be inserted into, 
+// quoted for use in a query. Generated columns are skipped.
+func getInsertableColumns(database string, table string) ([]string) {
+	query := `SELECT
+		COLUMN_NAME
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		AND TABLE_NAME = ?
+		AND EXTRA NOT LIKE '%GENERATED%'
+		ORDER BY ORDINAL_POSITION ASC;`
+
+	result, err := Query(query, database, table)
+	exitOnError(err, "Can not access column information for table '%s'.", table)
+
+	columns := make([]string, 0)
+	for _, row := range result {
+		columns = append(columns, fmt.Sprintf("`%s`", row.Str(0)))
+	}
+	return columns
+}
+
+// List the backups taken of a managed database, newest first.
+func GetBackups(database string) (list backupList) {
+
+	assertDatabaseIsManaged(database)
+
+	query := configSql(`SELECT b.backupDatabase,
+		b.revision,
+		b.direction,
+		b.tableNames,
+		b.dateCreated
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.backups AS b ON b.databaseId = id.id
+		WHERE id.name = ?
+		ORDER BY b.id DESC;`)
+
+	rows, err := Query(query, database)
+	exitOnError(err, "Can not retrieve backups of database '%s'.", database)
+
+	list = make(backupList, 0)
+	for _, row := range rows {
+		list = append(list, backup{row.Str(0), row.Str(1), row.Str(2), row.Str(3), row.Str(4)})
+	}
+	return
+}
+
+// Get a backup of a managed database by the name of the database holding it. 
+// The second return value is false if there is no such backup.
+func getBackup(database string, name string) (found backup, ok bool) {
+	for _, entry := range GetBackups(database) {
+		if entry.Database == name {
+			return entry, true
+		}
+	}
+	return
+}
+
+// Restore the rows of the tables held in a backup to a managed database. Tables 
+// that no longer exist are recreated. Rows of existing tables are inserted or, 
+// if a row with the same key exists, updated using the columns found in both 
+// the backup and the table. Columns that no longer exist are not restored. 
+// Returns false if there is no such backup.
+func RestoreBackup(database string, name string) (bool) {
+	entry, ok := getBackup(database, name)
+	if !ok {
+		return false
+	}
+
+	assertUseDatabase(database)
+	err := ExecUnsafe("SET FOREIGN_KEY_CHECKS = 0;")
+	exitOnError(err, "Error occurred disabling foreign key checks.")
+
+	for _, table := range entry.TableNames() {
+		if !tableExists(database, table) {
+			err = ExecUnsafe("CREATE TABLE `%s`.`%s` LIKE `%s`.`%s`;", database, table, name, table)
+			exitOnError(err, "Can not recreate table '%s' from backup database '%s'.", table, name)
+		}
+		restoreTableRows(database, name, table)
+	}
+
+	err = ExecUnsafe("SET FOREIGN_KEY_CHECKS = 1;")
+	exitOnError(err, "Error occurred enabling foreign key checks.")
+	return true
+}
+
+// Copy the rows of a table in a backup database to the same table in a managed 
+// database, updating any rows that already exist.
+func restoreTableRows(database string, name string, table string) {
+	available := make(map[string]bool)
+	for _, column := range getInsertableColumns(database, table) {
+		available[column] = true
+	}
+
+	columns := make([]string, 0)
+	updates := make([]string, 0)
+	for _, column := range getInsertableColumns(name, table) {
+		if available[column] {
+			columns = append(columns, column)
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		}
+	}
+	if len(columns) == 0 {
+		log.Printf("Table '%s' has no columns in common with its backup, skipping.\n", table)
+		return
+	}
+
+	columnList := strings.Join(columns, ", ")
+	err := ExecUnsafe("INSERT INTO `%s`.`%s` (%s) SELECT %s FROM `%s`.`%s` ON DUPLICATE KEY UPDATE %s;", database, table, columnList, columnList, name, table, strings.Join(updates, ", "))
+	exitOnError(err, "Can not restore the rows of table '%s' from backup database '%s'.", table, name)
+}
+
+// Check if a table exists in a database.
+func tableExists(database string, table string) (bool) {
+	query := `SELECT TABLE_NAME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?
+		AND TABLE_NAME = ?
+		LIMIT 1;`
+	row, err := QueryRow(query, database, table)
+	exitOnError(err, "Error occurred checking table '%s' exists.", table)
+	return len(row) > 0
+}
+
+// Remove a backup of a managed database, dropping the database holding it. 
+// Returns false if there is no such backup.
+func RemoveBackup(database string, name string) (bool) {
+	if _, ok := getBackup(database, name); !ok {
+		return false
+	}
+
+	err := dropDatabase(name)
+	exitOnError(err, "Can not drop backup database '%s'.", name)
+
+	err = Exec(configSql("DELETE FROM snap_config.backups WHERE backupDatabase = ?;"), name)
+	exitOnError(err, "Error occurred while removing the record of backup database '%s'.", name)
+	return true
+}
@@ -0,0 +1,43 @@
+package database
+
+// Imports.
+import "strconv"
+import "strings"
+
+// Create a temporary copy of a managed database at a revision, returning the 
+// name of the copy. The copy is deleted when the program exits if it hasn't 
+// been deleted before.
+func CreateTempCopy(database string, revision uint64) (string) {
+	temp := generateTempDatabaseName()
+	CopyDatabase(database, temp, revision)
+	return temp
+}
+
+// Delete all temporary databases created by this process.
+func DeleteTempDatabases() {
+	deleteTempDatabases()
+}
+
+// Run an SQL assertion against a database. The assertion passes if it runs 
+// without error and either returns no result set, as with a statement that 
+// modifies data, or returns a first column in its first row which is not 
+// NULL, zero or empty. An error running the SQL is returned so it can be 
+// reported, it doesn't halt the program.
+func RunSqlAssertion(database string, sql string) (passed bool, err error) {
+	assertUseDatabase(database)
+	rows, result, err := db.Query(sql)
+	if err != nil {
+		return false, err
+	}
+	if result.StatusOnly() {
+		return true, nil
+	}
+	if len(rows) == 0 || rows[0][0] == nil {
+		return false, nil
+	}
+	value := strings.TrimSpace(rows[0].Str(0))
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number != 0, nil
+	}
+	return value != "", nil
+}
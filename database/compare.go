@@ -0,0 +1,34 @@
+package database
+
+// Imports.
+import "github.com/nomad-software/snap/config"
+import "log"
+
+// Generate the normalised schema of a live database on a named server. A new 
+// connection is made to the server and used in place of the default one while 
+// the schema is generated. The object filters of the database are taken from 
+// the config database on the default server.
+func GenerateServerSchema(server string, databaseName string) (string) {
+	serverConfig, ok := config.GetConfig().Server(server)
+	if !ok {
+		log.Fatalf("Server '%s' is not configured.\n", server)
+	}
+
+	filters := GetObjectFilters(databaseName)
+
+	conn, err := connect(serverConfig)
+	exitOnError(err, "Connection to server '%s' could not be established.", server)
+
+	previous := db
+	db        = conn
+	defer func() {
+		conn.Close()
+		db = previous
+	}()
+
+	if !DatabaseExists(databaseName) {
+		log.Fatalf("Database '%s' does not exist on server '%s'.\n", databaseName, server)
+	}
+
+	return NormaliseSchema(databaseName, generateRawSchema(databaseName, filters))
+}
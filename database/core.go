@@ -1,6 +1,7 @@
 package database
 
 // Imports.
+import "fmt"
 import "github.com/nomad-software/snap/config"
 import "github.com/ziutek/mymysql/mysql"
 import "log"
@@ -9,30 +10,80 @@ import _ "github.com/ziutek/mymysql/native"
 // Package database struct.
 var db mysql.Conn
 var tx mysql.Transaction
-var tempDatabases []string = make([]string, 0)
+var connectionConfig config.Config
 
-// Handle a fatal error that will halt program execution. Rollback any 
-// transaction that is pending.
+// Set while a function is being tried so fatal errors are returned from it 
+// instead of halting program execution.
+var trying bool
+
+// A fatal error raised while a function is being tried.
+type fatalError struct {
+	Message string
+}
+
+// Error interface implementation.
+func (this fatalError) Error() (string) {
+	return this.Message
+}
+
+// Handle a fatal error that will halt program execution.
 func exitOnError(err error, format string, values ...interface{}) {
 	if err != nil {
-		Rollback()
-		deleteTempDatabases()
 		log.Println(err)
-		log.Fatalf(format + "\n", values...)
+		halt(format, values...)
 	}
 }
 
-// Delete any temporary database that have been created.
-func deleteTempDatabases() {
-	if len(tempDatabases) > 0 {
-		for _, database := range tempDatabases {
-			_ = dropDatabase(database)
-		}
+// Halt program execution with a fatal error. Rollback any transaction that is 
+// pending and delete any temporary databases first. If a function is being 
+// tried the error is returned from it instead.
+func halt(format string, values ...interface{}) {
+	Rollback()
+	deleteTempDatabases()
+	if trying {
+		panic(fatalError{fmt.Sprintf(format, values...)})
 	}
+	log.Fatalf(format + "\n", values...)
+}
+
+// Try a function using the database, returning the error of any failure that 
+// would otherwise halt program execution. This allows a long running process, 
+// such as the API server, to survive a bad request. A lost connection is 
+// re-established before the function is called.
+func Try(function func()) (err error) {
+	if err = reconnect(); err != nil {
+		log.Println(err)
+		return fatalError{"Database connection could not be re-established."}
+	}
+
+	trying = true
+	defer func() {
+		trying = false
+		if recovered := recover(); recovered != nil {
+			fatal, ok := recovered.(fatalError)
+			if !ok {
+				panic(recovered)
+			}
+			err = fatal
+		}
+	}()
+
+	function()
+	return
 }
 
-// Establishes a connection to the database.
+// Establishes a connection to the database. Temporary databases are cleaned 
+// up if the program is interrupted.
 func Open(config config.Config) {
+	_db, err := connect(config)
+	exitOnError(err, "Database connection could not be established.")
+	db               = _db
+	connectionConfig = config
+	handleInterrupts()
+}
+
+// Create a new connection to the database.
+func connect(config config.Config) (mysql.Conn, error) {
 	protocol      := config.Database.Protocol
 	localAddress  := ""
 	remoteAddress := config.Database.Host + ":" + config.Database.Port
@@ -41,12 +92,28 @@ func Open(config config.Config) {
 	database      := ""
 	_db := mysql.New(protocol, localAddress, remoteAddress, user, password, database)
 	err := _db.Connect()
-	exitOnError(err, "Database connection could not be established.")
-	db = _db
+	return _db, err
 }
 
-// Close the datbase connection.
+// Re-establish the connection to the database if it has been lost, e.g. 
+// because the server closed it after being idle.
+func reconnect() (error) {
+	if db == nil {
+		return fmt.Errorf("No database connection has been opened.")
+	}
+	if db.Ping() == nil {
+		return nil
+	}
+	return db.Reconnect()
+}
+
+// Close the datbase connection. Any temporary databases left behind, e.g. 
+// because of a panic, are deleted first.
 func Close() {
+	if tx != nil && tx.IsValid() {
+		_ = tx.Rollback()
+	}
+	deleteTempDatabases()
 	db.Close()
 }
 
@@ -2,19 +2,37 @@ package database
 
 // Imports.
 import "fmt"
+import "github.com/nomad-software/snap/config"
+import "github.com/nomad-software/snap/sanitise"
 import "strings"
 
 // Generate the full schema of the named database (not including data) in SQL 
 // format as a string. The format of the generated SQL is that which would be 
-// generated from the mysqldump tool.
+// generated from the mysqldump tool, normalised using the rules configured for 
+// the database.
 func GenerateSchema(databaseName string) (string) {
+	filters := GetObjectFilters(databaseName)
+	return NormaliseSchema(databaseName, generateRawSchema(databaseName, filters))
+}
+
+// Normalise a schema using the rules configured for the named database.
+func NormaliseSchema(databaseName string, sql string) (string) {
+	rules := config.GetConfig().NormalisationRules(databaseName)
+	return sanitise.NormaliseSql(sql, rules)
+}
+
+// Generate the full schema of the named database exactly as it is output by 
+// the server. Only objects allowed by the passed filters are included.
+func generateRawSchema(databaseName string, filters objectFilters) (string) {
 	assertUseDatabase(databaseName)
 	output := []string{
 		exportDatabase(databaseName),
-		exportTables(databaseName),
-		exportFunctions(databaseName),
-		exportProcedures(databaseName),
-		exportTriggers(databaseName),
+		exportTables(databaseName, filters),
+		exportFunctions(databaseName, filters),
+		exportProcedures(databaseName, filters),
+		exportViews(databaseName, filters),
+		exportTriggers(databaseName, filters),
+		exportEvents(databaseName, filters),
 	}
 	// Filter out empty lines.
 	sqlFragments := make([]string, 0)
@@ -82,8 +100,8 @@ func exportDatabase(databaseName string) string {
 
 // Export table SQL string for an entire database. This function assumes the 
 // database exists and is being used.
-func exportTables(databaseName string) (string) {
-	tables := getAllTableNames(databaseName)
+func exportTables(databaseName string, filters objectFilters) (string) {
+	tables := getAllTableNames(databaseName, filters)
 	sqlFragments := make([]string, 0)
 	for _, table := range tables {
 		sqlFragments = append(sqlFragments, exportTable(table))
@@ -92,14 +110,17 @@ func exportTables(databaseName string) (string) {
 	return strings.Join(sqlFragments, "\n\n");
 }
 
-// Retrieve all the table names from the passed database. This function assumes 
-// the database exists and is being used.
-func getAllTableNames(databaseName string) ([]string) {
-	rows, err := Query("SHOW TABLES;")
+// Retrieve all the table names from the passed database which are allowed by 
+// the passed filters. Views are excluded as they are exported separately. This 
+// function assumes the database exists and is being used.
+func getAllTableNames(databaseName string, filters objectFilters) ([]string) {
+	rows, err := Query("SHOW FULL TABLES WHERE Table_type = 'BASE TABLE';")
 	exitOnError(err, "Can not access table information for database '%s'.", databaseName)
 	var tables = make([]string, 0)
 	for _, row := range rows {
-		tables = append(tables, row.Str(0))
+		if filters.Allows(row.Str(0)) {
+			tables = append(tables, row.Str(0))
+		}
 	}
 	return tables
 }
@@ -115,8 +136,8 @@ func exportTable(tableName string) (string) {
 
 // Export function SQL string for an entire database. This function assumes the 
 // database exists and is being used.
-func exportFunctions(databaseName string) (string) {
-	functions := getAllFunctionNames(databaseName)
+func exportFunctions(databaseName string, filters objectFilters) (string) {
+	functions := getAllFunctionNames(databaseName, filters)
 	sqlFragments := make([]string, 0)
 	for _, function := range functions {
 		sqlFragments = append(sqlFragments, exportFunction(function))
@@ -126,14 +147,17 @@ func exportFunctions(databaseName string) (string) {
 	return strings.Join(sqlFragments, "\n\n");
 }
 
-// Retrieve all the function names from the passed database. This function 
-// assumes the database exists and is being used.
-func getAllFunctionNames(databaseName string) ([]string) {
+// Retrieve all the function names from the passed database which are allowed 
+// by the passed filters. This function assumes the database exists and is 
+// being used.
+func getAllFunctionNames(databaseName string, filters objectFilters) ([]string) {
 	rows, err := Query("SHOW FUNCTION STATUS WHERE Db = ?;", databaseName)
 	exitOnError(err, "Can not access function information for database '%s'.", databaseName)
 	var functions = make([]string, 0)
 	for _, row := range rows {
-		functions = append(functions, row.Str(1))
+		if filters.Allows(row.Str(1)) {
+			functions = append(functions, row.Str(1))
+		}
 	}
 	return functions
 }
@@ -149,8 +173,8 @@ func exportFunction(functionName string) (string) {
 
 // Export procedure SQL string for an entire database. This function assumes 
 // the database exists and is being used.
-func exportProcedures(databaseName string) (string) {
-	procedures := getAllProcedureNames(databaseName)
+func exportProcedures(databaseName string, filters objectFilters) (string) {
+	procedures := getAllProcedureNames(databaseName, filters)
 	sqlFragments := make([]string, 0)
 	for _, procedure := range procedures {
 		sqlFragments = append(sqlFragments, exportProcedure(procedure))
@@ -160,14 +184,17 @@ func exportProcedures(databaseName string) (string) {
 	return strings.Join(sqlFragments, "\n\n");
 }
 
-// Retrieve all the procedure names from the passed database. This function 
-// assumes the database exists and is being used.
-func getAllProcedureNames(databaseName string) ([]string) {
+// Retrieve all the procedure names from the passed database which are allowed 
+// by the passed filters. This function assumes the database exists and is 
+// being used.
+func getAllProcedureNames(databaseName string, filters objectFilters) ([]string) {
 	rows, err := Query("SHOW PROCEDURE STATUS WHERE Db = ?;", databaseName)
 	exitOnError(err, "Can not access procedure information for database '%s'.", databaseName)
 	var procedures = make([]string, 0)
 	for _, row := range rows {
-		procedures = append(procedures, row.Str(1))
+		if filters.Allows(row.Str(1)) {
+			procedures = append(procedures, row.Str(1))
+		}
 	}
 	return procedures
 }
@@ -184,8 +211,8 @@ func exportProcedure(procedureName string) (string) {
 
 // Export trigger SQL string for an entire database. This function assumes the 
 // database exists and is being used.
-func exportTriggers(databaseName string) (string) {
-	triggers := getAllTriggerNames(databaseName)
+func exportTriggers(databaseName string, filters objectFilters) (string) {
+	triggers := getAllTriggerNames(databaseName, filters)
 	sqlFragments := make([]string, 0)
 	for _, trigger := range triggers {
 		sqlFragments = append(sqlFragments, exportTrigger(trigger))
@@ -195,14 +222,18 @@ func exportTriggers(databaseName string) (string) {
 	return strings.Join(sqlFragments, "\n\n");
 }
 
-// Retrieve all the trigger names from the passed database. This function 
-// assumes the database exists and is being used.
-func getAllTriggerNames(databaseName string) ([]string) {
+// Retrieve all the trigger names from the passed database which are allowed 
+// by the passed filters. This function assumes the database exists and is 
+// being used.
+func getAllTriggerNames(databaseName string, filters objectFilters) ([]string) {
 	rows, err := QueryUnsafe("SHOW TRIGGERS FROM `%s`;", databaseName)
 	exitOnError(err, "Can not access trigger information for database '%s'.", databaseName)
 	var triggers = make([]string, 0)
 	for _, row := range rows {
-		triggers = append(triggers, row.Str(0))
+		// Triggers are also excluded when the table they belong to is.
+		if filters.Allows(row.Str(0)) && filters.Allows(row.Str(2)) {
+			triggers = append(triggers, row.Str(0))
+		}
 	}
 	return triggers
 }
@@ -215,3 +246,112 @@ func exportTrigger(triggerName string) (string) {
 	// fragment like this.
 	return row.Str(2) + "$$"
 }
+
+// Export view SQL string for an entire database. Views are exported in order of 
+// their dependencies on each other so the SQL can be executed as is. This 
+// function assumes the database exists and is being used.
+func exportViews(databaseName string, filters objectFilters) (string) {
+	views := getAllViewNames(databaseName, filters)
+	sqlFragments := make([]string, 0)
+	for _, view := range views {
+		sqlFragments = append(sqlFragments, exportView(view))
+	}
+	sqlFragments = prependHeaderFragment("Views", sqlFragments)
+	return strings.Join(sqlFragments, "\n\n");
+}
+
+// Retrieve all the view names from the passed database which are allowed by 
+// the passed filters, ordered so that any view appears after the views it 
+// depends upon. This function assumes the database exists and is being used.
+func getAllViewNames(databaseName string, filters objectFilters) ([]string) {
+	query := `SELECT
+		TABLE_NAME,
+		VIEW_DEFINITION
+		FROM information_schema.VIEWS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME ASC;`
+	rows, err := Query(query, databaseName)
+	exitOnError(err, "Can not access view information for database '%s'.", databaseName)
+	var views = make([]string, 0)
+	var definitions = make(map[string]string)
+	for _, row := range rows {
+		if filters.Allows(row.Str(0)) {
+			views = append(views, row.Str(0))
+			definitions[row.Str(0)] = row.Str(1)
+		}
+	}
+	return sortViewsByDependency(databaseName, views, definitions)
+}
+
+// Sort the passed view names so that each view follows every other view it 
+// references. View definitions stored by MySql always reference objects using 
+// fully qualified quoted names so a simple search is enough to find them. 
+// Views without dependencies between them keep their passed order.
+func sortViewsByDependency(databaseName string, views []string, definitions map[string]string) ([]string) {
+	sorted  := make([]string, 0, len(views))
+	visited := make(map[string]bool)
+	var visit func(view string)
+	visit = func(view string) {
+		if visited[view] {
+			return
+		}
+		visited[view] = true
+		for _, dependency := range views {
+			reference := fmt.Sprintf("`%s`.`%s`", databaseName, dependency)
+			if dependency != view && strings.Contains(definitions[view], reference) {
+				visit(dependency)
+			}
+		}
+		sorted = append(sorted, view)
+	}
+	for _, view := range views {
+		visit(view)
+	}
+	return sorted
+}
+
+// Export the SQL for one view. This function assumes the view exists.
+func exportView(viewName string) (string) {
+	row, err := QueryRowUnsafe("SHOW CREATE VIEW `%s`;", viewName)
+	exitOnError(err, "Can not read creation information for view '%s'.", viewName)
+	// The ending semi-colon is always missing when retrieving an SQL fragment 
+	// like this.
+	return row.Str(1) + ";"
+}
+
+// Export event SQL string for an entire database. This function assumes the 
+// database exists and is being used.
+func exportEvents(databaseName string, filters objectFilters) (string) {
+	events := getAllEventNames(databaseName, filters)
+	sqlFragments := make([]string, 0)
+	for _, event := range events {
+		sqlFragments = append(sqlFragments, exportEvent(event))
+	}
+	sqlFragments = wrapFragmentsWithSafeDelimiters(sqlFragments)
+	sqlFragments = prependHeaderFragment("Events", sqlFragments)
+	return strings.Join(sqlFragments, "\n\n");
+}
+
+// Retrieve all the event names from the passed database which are allowed 
+// by the passed filters. This function assumes the database exists and is 
+// being used.
+func getAllEventNames(databaseName string, filters objectFilters) ([]string) {
+	rows, err := QueryUnsafe("SHOW EVENTS FROM `%s`;", databaseName)
+	exitOnError(err, "Can not access event information for database '%s'.", databaseName)
+	var events = make([]string, 0)
+	for _, row := range rows {
+		if filters.Allows(row.Str(1)) {
+			events = append(events, row.Str(1))
+		}
+	}
+	return events
+}
+
+// Export the SQL for one event. This function assumes the event exists.
+func exportEvent(eventName string) (string) {
+	row, err := QueryRowUnsafe("SHOW CREATE EVENT `%s`;", eventName)
+	exitOnError(err, "Can not read creation information for event '%s'.", eventName)
+	// The ending safe delimiter is always missing when retrieving an SQL 
+	// fragment like this.
+	return row.Str(3) + "$$"
+}
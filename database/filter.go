@@ -0,0 +1,111 @@
+package database
+
+// Imports.
+import "fmt"
+import "path"
+
+// Types of object filter.
+const INCLUDE_FILTER string = "include"
+const EXCLUDE_FILTER string = "exclude"
+
+// An object filter type. Patterns use shell glob syntax.
+type objectFilter struct {
+	Type string
+	Pattern string
+}
+
+// A collection of object filters.
+type objectFilters []objectFilter
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this objectFilter) TabbedString() (string) {
+	return fmt.Sprintf("%s\t%s", this.Type, this.Pattern)
+}
+
+// Check if an object should be tracked. If any include filters exist the name 
+// must match at least one of them. The name must not match any exclude filter.
+func (this objectFilters) Allows(name string) (bool) {
+	included := true
+	for _, filter := range this {
+		if filter.Type == INCLUDE_FILTER {
+			included = false
+			break
+		}
+	}
+	for _, filter := range this {
+		matched, _ := path.Match(filter.Pattern, name)
+		if matched && filter.Type == EXCLUDE_FILTER {
+			return false
+		} else if matched {
+			included = true
+		}
+	}
+	return included
+}
+
+// Check a filter pattern is valid.
+func ValidFilterPattern(pattern string) (bool) {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// Get the object filters of the passed database. Filters are stored against 
+// the database name so they can be added before the database is initialised.
+func GetObjectFilters(database string) (filters objectFilters) {
+
+	query := configSql(`SELECT
+		f.filterType,
+		f.pattern
+		FROM snap_config.objectFilters AS f
+		WHERE f.databaseName = ?
+		ORDER BY f.filterType ASC, f.pattern ASC;`)
+
+	rows, err := Query(query, database)
+	exitOnError(err, "Can not retrieve object filters for database '%s'.", database)
+
+	filters = make([]objectFilter, 0)
+	for _, row := range rows {
+		filters = append(filters, objectFilter{row.Str(0), row.Str(1)})
+	}
+	return
+}
+
+// Add an object filter to the passed database.
+func AddObjectFilter(database string, filterType string, pattern string) {
+
+	query := configSql(`INSERT INTO snap_config.objectFilters
+		(databaseName, filterType, pattern)
+		VALUES (?, ?, ?);`)
+
+	_, err := InsertRow(query, database, filterType, pattern)
+	exitOnError(err, "Error occurred adding %s filter '%s' to database '%s'.", filterType, pattern, database)
+}
+
+// Remove an object filter from the passed database. Returns false if the 
+// pattern didn't exist as a filter of that type.
+func RemoveObjectFilter(database string, filterType string, pattern string) (bool) {
+
+	query := configSql(`SELECT f.id
+		FROM snap_config.objectFilters AS f
+		WHERE f.databaseName = ?
+		AND f.filterType = ?
+		AND f.pattern = ?
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, database, filterType, pattern)
+	exitOnError(err, "Error occurred removing %s filter '%s' from database '%s'.", filterType, pattern, database)
+
+	if len(row) == 0 {
+		return false
+	}
+
+	query = configSql(`DELETE FROM snap_config.objectFilters
+		WHERE databaseName = ?
+		AND filterType = ?
+		AND pattern = ?;`)
+
+	err = Exec(query, database, filterType, pattern)
+	exitOnError(err, "Error occurred removing %s filter '%s' from database '%s'.", filterType, pattern, database)
+
+	return true
+}
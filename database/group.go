@@ -0,0 +1,249 @@
+package database
+
+// Imports.
+import "fmt"
+import "log"
+
+// Roles of the databases in a group.
+const HISTORY_ROLE string = "history"
+const MEMBER_ROLE string = "member"
+
+// The SQL to create the database groups table.
+const databaseGroupsTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.databaseGroups (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(64) NOT NULL,
+  historyDatabaseId INT UNSIGNED NOT NULL,
+  dateCreated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE INDEX uniqueGroupName (name ASC),
+  UNIQUE INDEX uniqueHistoryDatabaseId (historyDatabaseId ASC),
+  CONSTRAINT fk_databaseGroups_initialisedDatabases
+    FOREIGN KEY (historyDatabaseId)
+    REFERENCES snap_config.initialisedDatabases (id)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;`
+
+// A group of databases sharing one history.
+type databaseGroup struct {
+	Name string
+	History string
+	Members string
+	Date string
+}
+
+// A collection of database groups.
+type databaseGroupList []databaseGroup
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this databaseGroup) TabbedString() (string) {
+	return fmt.Sprintf("%s\t%s\t%s\t%s", this.Name, this.History, this.Members, this.Date)
+}
+
+// A database in a group.
+type groupMember struct {
+	Name string
+	Role string
+	Revision uint64
+}
+
+// A collection of databases in a group.
+type groupMemberList []groupMember
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this groupMember) TabbedString() (string) {
+	return fmt.Sprintf("%s\t%s\t%d", this.Name, this.Role, this.Revision)
+}
+
+// Get the name of the database holding the history of a group. The second 
+// return value is false if the group doesn't exist.
+func getGroupHistoryDatabase(group string) (history string, found bool) {
+	query := configSql(`SELECT id.name
+		FROM snap_config.databaseGroups AS dg
+		INNER JOIN snap_config.initialisedDatabases AS id ON id.id = dg.historyDatabaseId
+		WHERE dg.name = ?
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, group)
+	exitOnError(err, "Error occurred retrieving group '%s'.", group)
+
+	if len(row) > 0 {
+		history = row.Str(0)
+		found   = true
+	}
+	return
+}
+
+// Assert that a group exists, returning the name of the database holding its 
+// history. If it doesn't exist throw a fatal error.
+func AssertGroupExists(group string) (string) {
+	history, found := getGroupHistoryDatabase(group)
+	if !found {
+		log.Fatalf("Group '%s' does not exist.\n", group)
+	}
+	return history
+}
+
+// Get the name of the database whose history is shared by a managed database. 
+// The second return value is false if the database has its own history.
+func getSharedHistoryDatabase(database string) (history string, shared bool) {
+	assertDatabaseIsManaged(database)
+
+	query := configSql(`SELECT history.name
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.initialisedDatabases AS history ON history.id = id.historyDatabaseId
+		WHERE id.name = ?
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, database)
+	exitOnError(err, "Error occurred retrieving the history of database '%s'.", database)
+
+	if len(row) > 0 {
+		history = row.Str(0)
+		shared  = true
+	}
+	return
+}
+
+// Assert that a managed database has its own history. If it shares the history 
+// of another database as a member of a group throw a fatal error.
+func AssertHasOwnHistory(database string) {
+	if history, shared := getSharedHistoryDatabase(database); shared {
+		halt("Database '%s' shares the history of database '%s' as a member of a group, use that database instead.", database, history)
+	}
+}
+
+// Create a group sharing the history of a managed database.
+func CreateGroup(group string, database string) {
+	AssertHasOwnHistory(database)
+	databaseId := getDatabaseId(database)
+
+	query := configSql(`INSERT INTO snap_config.databaseGroups
+		(name, historyDatabaseId)
+		VALUES (?, ?);`)
+
+	err := Exec(query, group, databaseId)
+	exitOnError(err, "Group '%s' already exists or database '%s' already holds the history of a group.", group, database)
+}
+
+// Delete a group. Returns false if the group still has members other than the 
+// database holding its history.
+func DeleteGroup(group string) (bool) {
+	AssertGroupExists(group)
+	if len(GetGroupMembers(group)) > 1 {
+		return false
+	}
+	err := Exec(configSql("DELETE FROM snap_config.databaseGroups WHERE name = ?;"), group)
+	exitOnError(err, "Error occurred deleting group '%s'.", group)
+	return true
+}
+
+// List all groups.
+func GetGroups() (list databaseGroupList) {
+
+	query := configSql(`SELECT dg.name,
+		history.name,
+		COUNT(member.id) + 1,
+		dg.dateCreated
+		FROM snap_config.databaseGroups AS dg
+		INNER JOIN snap_config.initialisedDatabases AS history ON history.id = dg.historyDatabaseId
+		LEFT JOIN snap_config.initialisedDatabases AS member ON member.historyDatabaseId = history.id
+		GROUP BY dg.id
+		ORDER BY dg.name ASC;`)
+
+	rows, err := Query(query)
+	exitOnError(err, "Can not retrieve list of groups.")
+
+	list = make(databaseGroupList, 0)
+	for _, row := range rows {
+		list = append(list, databaseGroup{row.Str(0), row.Str(1), row.Str(2), row.Str(3)})
+	}
+	return
+}
+
+// List the databases in a group along with their current schema revision. The 
+// database holding the history is listed first.
+func GetGroupMembers(group string) (list groupMemberList) {
+
+	history := AssertGroupExists(group)
+
+	query := configSql(`SELECT id.name,
+		IF(id.historyDatabaseId IS NULL, ?, ?),
+		id.currentSchemaRevision
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.initialisedDatabases AS history ON history.name = ?
+		WHERE id.id = history.id
+		OR id.historyDatabaseId = history.id
+		ORDER BY id.historyDatabaseId IS NOT NULL ASC, id.name ASC;`)
+
+	rows, err := Query(query, HISTORY_ROLE, MEMBER_ROLE, history)
+	exitOnError(err, "Can not retrieve the members of group '%s'.", group)
+
+	list = make(groupMemberList, 0)
+	for _, row := range rows {
+		list = append(list, groupMember{row.Str(0), row.Str(1), row.Uint64(2)})
+	}
+	return
+}
+
+// Add a database to a group. The database shares the history of the group and 
+// is recorded as being at the passed revision.
+func AddGroupMember(group string, database string, revision uint64) {
+	history := AssertGroupExists(group)
+	if databaseIsManaged(database) {
+		log.Fatalf("Database '%s' is already being managed.\n", database)
+	}
+	historyId := getDatabaseId(history)
+
+	query := configSql(`INSERT INTO snap_config.initialisedDatabases
+		(name, currentSchemaRevision, historyDatabaseId)
+		VALUES (?, ?, ?);`)
+
+	err := Exec(query, database, revision, historyId)
+	exitOnError(err, "Error occurred adding database '%s' to group '%s'.", database, group)
+}
+
+// Remove a database from a group, after which it's no longer managed. Returns 
+// false if the database isn't a member of the group. The database holding the 
+// history of the group can't be removed.
+func RemoveGroupMember(group string, database string) (bool) {
+	history := AssertGroupExists(group)
+	if !databaseIsManaged(database) {
+		return false
+	}
+	if shared, ok := getSharedHistoryDatabase(database); !ok || shared != history {
+		return false
+	}
+	err := Exec(configSql("DELETE FROM snap_config.initialisedDatabases WHERE name = ?;"), database)
+	exitOnError(err, "Error occurred removing database '%s' from group '%s'.", database, group)
+	return true
+}
+
+// Find the revision of a group's history matching the schema of a database. An 
+// empty database is at revision zero. The second return value is false if no 
+// revision matches.
+func DetectGroupRevision(group string, database string) (revision uint64, found bool) {
+	history := AssertGroupExists(group)
+	if databaseIsEmpty(database) {
+		return 0, true
+	}
+	filters := GetObjectFilters(history)
+	live    := generateComparableSchema(database, history, filters)
+	for revision = GetHeadRevision(history); revision > 0; revision-- {
+		if live == NormaliseSchema(history, GetSchema(history, revision)) {
+			return revision, true
+		}
+	}
+	return 0, false
+}
+
+// Check if a database contains no objects.
+func databaseIsEmpty(database string) (bool) {
+	query := `SELECT
+		(SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?) +
+		(SELECT COUNT(*) FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ?) +
+		(SELECT COUNT(*) FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ?);`
+	row, err := QueryRow(query, database, database, database)
+	exitOnError(err, "Error occurred checking if database '%s' is empty.", database)
+	return row.Uint64(0) == 0
+}
@@ -1,31 +1,91 @@
 package database
 
 // Imports.
+import "fmt"
 import "log"
+import "regexp"
+import "strings"
+
+// The name the SQL of this package uses to refer to the config database. It's 
+// replaced with the name configured for the connection before the SQL is run, 
+// so separate snap histories can be kept on the same server.
+const CONFIG_DATABASE string = "snap_config"
+
+// Names the config database can be given.
+var configDatabaseNamePattern = regexp.MustCompile(`^[A-Za-z0-9_$]{1,64}$`)
+
+// The SQL to create the object filters table.
+const objectFiltersTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.objectFilters (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  databaseName VARCHAR(64) NOT NULL,
+  filterType ENUM('include', 'exclude') NOT NULL,
+  pattern VARCHAR(255) NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE INDEX uniqueDatabaseNameAndPattern (databaseName ASC, filterType ASC, pattern ASC))
+ENGINE = InnoDB;`
+
+// The SQL to add the status column to the revisions table.
+const revisionStatusColumnSql string = `ALTER TABLE snap_config.revisions
+  ADD COLUMN status ENUM('pending', 'complete') NOT NULL DEFAULT 'complete' COMMENT 'Pending until the update SQL has been applied.' AFTER fullSql;`
+
+// The SQL to add the history database column to the initialised databases 
+// table.
+const historyDatabaseColumnSql string = `ALTER TABLE snap_config.initialisedDatabases
+  ADD COLUMN historyDatabaseId INT UNSIGNED NULL DEFAULT NULL COMMENT 'The database whose revisions are shared by members of a group.' AFTER currentSchemaRevision,
+  ADD CONSTRAINT fk_initialisedDatabases_historyDatabase
+    FOREIGN KEY (historyDatabaseId)
+    REFERENCES snap_config.initialisedDatabases (id)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION;`
+
+// The SQL to enlarge the SQL columns of the revisions table of config 
+// databases created when they were limited to 64KB.
+const revisionSqlColumnsSql string = `ALTER TABLE snap_config.revisions
+  MODIFY COLUMN upSql LONGTEXT NULL DEFAULT NULL,
+  MODIFY COLUMN downSql LONGTEXT NULL DEFAULT NULL,
+  MODIFY COLUMN fullSql LONGBLOB NOT NULL COMMENT 'SQL snapshot after applying update SQL.';`
+
+// The SQL to add the snapshot encoding column to the revisions table.
+const revisionEncodingColumnSql string = `ALTER TABLE snap_config.revisions
+  ADD COLUMN fullSqlEncoding ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text' COMMENT 'How the SQL snapshot is stored.' AFTER fullSql;`
+
+// Return the name of the config database configured for the connection. If 
+// the name isn't valid a fatal error is thrown.
+func configDatabaseName() (string) {
+	name := connectionConfig.Database.ConfigDatabase
+	if !configDatabaseNamePattern.MatchString(name) {
+		log.Fatalf("The config database name '%s' is not valid, use up to 64 letters, numbers, underscores or dollar signs.\n", name)
+	}
+	return name
+}
+
+// Return the passed SQL referring to the config database configured for the 
+// connection instead of the default one. All SQL using the config database 
+// must qualify its tables with the default name and be passed through here.
+func configSql(sql string) (string) {
+	return strings.Replace(sql, CONFIG_DATABASE + ".", fmt.Sprintf("`%s`.", configDatabaseName()), -1)
+}
 
 // Check if the snap config database exists. if it doesn't, create it.
 func AssertConfigDatabaseExists() {
-	if !DatabaseExists("snap_config") {
-		log.Println("Snap config database does not exist.")
+	if !DatabaseExists(configDatabaseName()) {
+		log.Printf("Snap config database '%s' does not exist.\n", configDatabaseName())
 		CreateConfigDatabase()
+	} else {
+		upgradeConfigDatabase()
 	}
 }
 
-// Switch to using the config database.
-func AssertUseConfigDatabase() {
-	assertUseDatabase("snap_config")
-}
-
 // Create the snap config database and all associated tables.
 func CreateConfigDatabase() {
-	sql := `
+	name := fmt.Sprintf("`%s`", configDatabaseName())
+	sql  := `
 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0;
 SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0;
 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='TRADITIONAL,ALLOW_INVALID_DATES';
 
-DROP SCHEMA IF EXISTS snap_config ;
-CREATE SCHEMA IF NOT EXISTS snap_config DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci ;
-USE snap_config ;
+DROP SCHEMA IF EXISTS `+name+` ;
+CREATE SCHEMA IF NOT EXISTS `+name+` DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci ;
 
 -- -----------------------------------------------------
 -- Table snap_config.initialisedDatabases
@@ -37,8 +97,14 @@ CREATE TABLE IF NOT EXISTS snap_config.initialisedDatabases (
   name VARCHAR(64) NOT NULL,
   dateInitialised TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   currentSchemaRevision INT UNSIGNED NOT NULL,
+  historyDatabaseId INT UNSIGNED NULL DEFAULT NULL COMMENT 'The database whose revisions are shared by members of a group.',
   PRIMARY KEY (id),
-  UNIQUE INDEX uniqueDatabaseName (name ASC))
+  UNIQUE INDEX uniqueDatabaseName (name ASC),
+  CONSTRAINT fk_initialisedDatabases_historyDatabase
+    FOREIGN KEY (historyDatabaseId)
+    REFERENCES snap_config.initialisedDatabases (id)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 
@@ -51,9 +117,11 @@ CREATE TABLE IF NOT EXISTS snap_config.revisions (
   id INT UNSIGNED NOT NULL AUTO_INCREMENT,
   databaseId INT UNSIGNED NOT NULL,
   revision INT UNSIGNED NOT NULL,
-  upSql TEXT NULL DEFAULT NULL,
-  downSql TEXT NULL DEFAULT NULL,
-  fullSql TEXT NOT NULL COMMENT 'SQL snapshot after applying update SQL.',
+  upSql LONGTEXT NULL DEFAULT NULL,
+  downSql LONGTEXT NULL DEFAULT NULL,
+  fullSql LONGBLOB NOT NULL COMMENT 'SQL snapshot after applying update SQL.',
+  fullSqlEncoding ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text' COMMENT 'How the SQL snapshot is stored.',
+  status ENUM('pending', 'complete') NOT NULL DEFAULT 'complete' COMMENT 'Pending until the update SQL has been applied.',
   comment VARCHAR(255) NOT NULL,
   author VARCHAR(255) NOT NULL,
   dateApplied TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -68,11 +136,68 @@ CREATE TABLE IF NOT EXISTS snap_config.revisions (
 ENGINE = InnoDB;
 
 
+-- -----------------------------------------------------
+-- Table snap_config.objectFilters
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.objectFilters ;
+
+`+objectFiltersTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.updateProgress
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.updateProgress ;
+
+`+updateProgressTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.backups
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.backups ;
+
+`+backupsTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.tempDatabases
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.tempDatabases ;
+
+`+tempDatabasesTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.databaseGroups
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.databaseGroups ;
+
+`+databaseGroupsTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.archivedRevisions
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.archivedRevisions ;
+
+`+archivedRevisionsTableSql+`
+
+
+-- -----------------------------------------------------
+-- Table snap_config.configVersions
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS snap_config.configVersions ;
+
+`+configVersionsTableSql+`
+
+
 SET SQL_MODE=@OLD_SQL_MODE;
 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS;
 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS;
 `
-	err := ExecMulti(sql)
+	err := ExecMulti(configSql(sql))
 	exitOnError(err, "Snap config database creation failed.")
+	recordConfigVersion(latestConfigVersion(), "Snap config database created.")
 	log.Println("Snap config database created successfully.")
 }
@@ -0,0 +1,172 @@
+package database
+
+// Imports.
+import "fmt"
+import "log"
+
+// Ways of recovering a pending revision.
+const RECOVER_AUTOMATICALLY string = "auto"
+const RECOVER_BY_FINALISING string = "finalise"
+const RECOVER_BY_ROLLING_BACK string = "rollback"
+
+// Outcomes of recovering a pending revision.
+const RECOVERY_NONE string = "none"
+const RECOVERY_FINALISED string = "finalised"
+const RECOVERY_ROLLED_BACK string = "rolled back"
+
+// Record a new revision as pending before its update SQL is applied. The 
+// pending revision is committed straight away so it survives the implicit 
+// commits of any DDL that follows. The id of the revision is returned.
+func createPendingRevision(database string, revision uint64, upSql string, downSql string, comment string, author string) (uint64) {
+	databaseId := getDatabaseId(database)
+
+	query := configSql(`INSERT INTO snap_config.revisions
+		(databaseId, revision, upSql, downSql, fullSql, status, comment, author)
+		VALUES (?, ?, ?, ?, '', 'pending', ?, ?);`)
+
+	id, err := InsertRow(query, databaseId, revision, upSql, downSql, comment, author)
+	exitOnError(err, "Error occurred while creating a new revision for database '%s'.", database)
+
+	return id
+}
+
+// Mark a pending revision as complete, storing the schema of the database 
+// after its update SQL was applied.
+func completePendingRevision(database string, id uint64, revision uint64, fullSql string) {
+	encoding, data := newSnapshot(database, revision, fullSql)
+
+	StartTransaction()
+
+		query := configSql(`UPDATE snap_config.revisions AS r
+			SET r.fullSql = ?, r.fullSqlEncoding = ?, r.status = 'complete'
+			WHERE r.id = ?
+			LIMIT 1;`)
+
+		err := Exec(query, data, encoding, id)
+		exitOnError(err, "Error occurred while completing revision '%d' for database '%s'.", revision, database)
+
+		setCurrentSchemaRevision(database, revision)
+
+	Commit()
+}
+
+// A pending revision type.
+type pendingRevision struct {
+	Id uint64
+	Revision uint64
+	UpSql string
+	DownSql string
+}
+
+// Get the pending revision of a managed database. The second return value is 
+// false if there is no pending revision.
+func getPendingRevision(database string) (pending pendingRevision, found bool) {
+
+	assertDatabaseIsManaged(database)
+
+	query := configSql(`SELECT
+		r.id,
+		r.revision,
+		r.upSql,
+		r.downSql
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'pending'
+		WHERE id.name = ?
+		ORDER BY r.revision ASC
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, database)
+	exitOnError(err, "Can not retrieve pending revisions for database '%s'.", database)
+
+	if len(row) > 0 {
+		pending = pendingRevision{row.Uint64(0), row.Uint64(1), row.Str(2), row.Str(3)}
+		found   = true
+	}
+	return
+}
+
+// Assert that a managed database has no pending revision. If it has throw a 
+// fatal error.
+func AssertNoPendingRevision(database string) {
+	if pending, found := getPendingRevision(database); found {
+		log.Printf("Revision '%d' of database '%s' was interrupted before it completed.\n", pending.Revision, database)
+		log.Fatalf("Run 'snap recover %s' to resolve it.\n", database)
+	}
+}
+
+// Recover a pending revision left by an interrupted commit. The live schema of 
+// the database is compared against the schema of the previous revision and 
+// the schema expected after applying the pending update SQL. Recovering 
+// automatically removes the pending revision if the update SQL was never 
+// applied and finalises it if it was. Recovering by rolling back applies the 
+// down SQL before removing the pending revision. An error is returned if the 
+// update SQL was only partially applied as this must be resolved by hand, or 
+// if the requested recovery doesn't fit the state of the database. The outcome 
+// of the recovery is returned.
+func RecoverPendingRevision(database string, mode string) (outcome string, revision uint64, err error) {
+	pending, found := getPendingRevision(database)
+	if !found {
+		return RECOVERY_NONE, 0, nil
+	}
+	revision = pending.Revision
+
+	filters  := GetObjectFilters(database)
+	previous := NormaliseSchema(database, GetSchema(database, revision - 1))
+	live     := GenerateSchema(database)
+
+	temp := generateTempDatabaseName()
+	CopyDatabase(database, temp, revision - 1)
+	err = applySqlToTempDatabase(temp, database, pending.UpSql)
+	exitOnError(err, "Error occurred applying the update SQL of revision '%d' to a copy of database '%s'.", revision, database)
+	expected := generateComparableSchema(temp, database, filters)
+	deleteTempDatabases()
+
+	applied   := live == expected
+	unapplied := live == previous
+
+	switch {
+		case applied && unapplied:
+			// The update SQL doesn't change the schema so there is no way 
+			// of telling whether it was applied.
+			if mode == RECOVER_BY_FINALISING {
+				completePendingRevision(database, pending.Id, revision, live)
+				return RECOVERY_FINALISED, revision, nil
+			} else if mode == RECOVER_BY_ROLLING_BACK {
+				removePendingRevision(database, pending)
+				return RECOVERY_ROLLED_BACK, revision, nil
+			}
+			return "", revision, fmt.Errorf("The update SQL doesn't change the schema so it can't be determined if it was applied. Choose to finalise or roll back the revision.")
+
+		case unapplied:
+			if mode == RECOVER_BY_FINALISING {
+				return "", revision, fmt.Errorf("The update SQL was never applied so the revision can't be finalised.")
+			}
+			removePendingRevision(database, pending)
+			return RECOVERY_ROLLED_BACK, revision, nil
+
+		case applied:
+			if mode == RECOVER_BY_ROLLING_BACK {
+				applyUpdateToDatabase(database, pending.DownSql)
+				if GenerateSchema(database) != previous {
+					return "", revision, fmt.Errorf("The down SQL did not restore the schema of revision '%d'.", revision - 1)
+				}
+				removePendingRevision(database, pending)
+				return RECOVERY_ROLLED_BACK, revision, nil
+			}
+			completePendingRevision(database, pending.Id, revision, live)
+			return RECOVERY_FINALISED, revision, nil
+	}
+
+	return "", revision, fmt.Errorf("The schema matches neither revision '%d' nor the result of applying revision '%d'. The update SQL may have been partially applied.", revision - 1, revision)
+}
+
+// Remove a pending revision.
+func removePendingRevision(database string, pending pendingRevision) {
+	query := configSql(`DELETE FROM snap_config.revisions
+		WHERE id = ?
+		AND status = 'pending'
+		LIMIT 1;`)
+
+	err := Exec(query, pending.Id)
+	exitOnError(err, "Error occurred while removing pending revision '%d' for database '%s'.", pending.Revision, database)
+}
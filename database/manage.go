@@ -0,0 +1,171 @@
+package database
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/config"
+import "log"
+
+// Reasons the revisions of a database were archived.
+const ARCHIVE_UNINIT string = "uninit"
+const ARCHIVE_REBASELINE string = "rebaseline"
+
+// The SQL to create the archived revisions table. Full SQL snapshots are 
+// archived the same way they were stored.
+const archivedRevisionsTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.archivedRevisions (
+  id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  databaseName VARCHAR(64) NOT NULL,
+  reason ENUM('uninit', 'rebaseline') NOT NULL,
+  dateArchived TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  revision INT UNSIGNED NOT NULL,
+  upSql LONGTEXT NULL DEFAULT NULL,
+  downSql LONGTEXT NULL DEFAULT NULL,
+  fullSql LONGBLOB NOT NULL,
+  fullSqlEncoding ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text',
+  comment VARCHAR(255) NOT NULL,
+  author VARCHAR(255) NOT NULL,
+  dateApplied TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX databaseNameAndDate (databaseName ASC, dateArchived ASC))
+ENGINE = InnoDB;`
+
+// The SQL to enlarge the SQL columns of an archived revisions table created 
+// when they were limited to 64KB.
+const archivedRevisionsSqlColumnsSql string = `ALTER TABLE snap_config.archivedRevisions
+  MODIFY COLUMN upSql LONGTEXT NULL DEFAULT NULL,
+  MODIFY COLUMN downSql LONGTEXT NULL DEFAULT NULL,
+  MODIFY COLUMN fullSql LONGBLOB NOT NULL;`
+
+// The SQL to add the snapshot encoding column to the archived revisions table.
+const archivedRevisionsEncodingColumnSql string = `ALTER TABLE snap_config.archivedRevisions
+  ADD COLUMN fullSqlEncoding ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text' AFTER fullSql;`
+
+// Assert that a managed database doesn't hold the history of a group with 
+// members. If it does throw a fatal error.
+func assertHoldsNoGroupHistory(database string, operation string) {
+	query := configSql(`SELECT dg.name
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.databaseGroups AS dg ON dg.historyDatabaseId = id.id
+		INNER JOIN snap_config.initialisedDatabases AS member ON member.historyDatabaseId = id.id
+		WHERE id.name = ?
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, database)
+	exitOnError(err, "Error occurred checking if database '%s' holds the history of a group.", database)
+
+	if len(row) > 0 {
+		log.Fatalf("Database '%s' holds the history of group '%s' which still has members, remove them before you %s it.\n", database, row.Str(0), operation)
+	}
+}
+
+// Copy the complete revisions of a managed database to the archive. All 
+// revisions archived together share the same archive date.
+func archiveRevisions(database string, databaseId uint64, reason string) {
+	query := configSql(`INSERT INTO snap_config.archivedRevisions
+		(databaseName, reason, dateArchived, revision, upSql, downSql, fullSql, fullSqlEncoding, comment, author, dateApplied)
+		SELECT ?, ?, NOW(), revision, upSql, downSql, fullSql, fullSqlEncoding, comment, author, dateApplied
+		FROM snap_config.revisions
+		WHERE databaseId = ?
+		AND status = 'complete'
+		ORDER BY revision ASC;`)
+
+	err := Exec(query, database, reason, databaseId)
+	exitOnError(err, "Error occurred archiving the revisions of database '%s'.", database)
+}
+
+// Stop managing a database. If requested its revisions are archived first. 
+// The database itself is left untouched. A member of a group is simply removed 
+// as its history belongs to the group.
+func UninitialiseDatabase(database string, archive bool) {
+	assertHoldsNoGroupHistory(database, "uninitialise")
+	_, shared  := getSharedHistoryDatabase(database)
+	databaseId := getDatabaseId(database)
+
+	StartTransaction()
+
+		if archive && !shared {
+			archiveRevisions(database, databaseId, ARCHIVE_UNINIT)
+		}
+
+		err := Exec(configSql("DELETE FROM snap_config.objectFilters WHERE databaseName = ?;"), database)
+		exitOnError(err, "Error occurred removing the object filters of database '%s'.", database)
+
+		err = Exec(configSql("DELETE FROM snap_config.initialisedDatabases WHERE id = ?;"), databaseId)
+		exitOnError(err, "Error occurred removing database '%s' from management.", database)
+
+	Commit()
+}
+
+// Move the history of a managed database to a database it has been renamed to. 
+// Fully qualified references to the old name held in its revisions are changed 
+// to the new name, as MySql always stores views with such references.
+func RenameDatabase(oldName string, newName string) {
+	if databaseIsManaged(newName) {
+		log.Fatalf("Database '%s' is already being managed.\n", newName)
+	}
+	_, shared  := getSharedHistoryDatabase(oldName)
+	databaseId := getDatabaseId(oldName)
+	oldPrefix  := fmt.Sprintf("`%s`.", oldName)
+	newPrefix  := fmt.Sprintf("`%s`.", newName)
+
+	StartTransaction()
+
+		err := Exec(configSql("UPDATE snap_config.initialisedDatabases SET name = ? WHERE id = ?;"), newName, databaseId)
+		exitOnError(err, "Error occurred renaming database '%s' to '%s'.", oldName, newName)
+
+		err = Exec(configSql("UPDATE snap_config.objectFilters SET databaseName = ? WHERE databaseName = ?;"), newName, oldName)
+		exitOnError(err, "Error occurred moving the object filters of database '%s'.", oldName)
+
+		if !shared {
+			query := configSql(`UPDATE snap_config.revisions
+				SET upSql = REPLACE(upSql, ?, ?),
+				downSql = REPLACE(downSql, ?, ?)
+				WHERE databaseId = ?;`)
+
+			err = Exec(query, oldPrefix, newPrefix, oldPrefix, newPrefix, databaseId)
+			exitOnError(err, "Error occurred changing references to database '%s' in its revisions.", oldName)
+
+			err = replaceInSnapshots(databaseId, oldPrefix, newPrefix)
+			exitOnError(err, "Error occurred changing references to database '%s' in its revisions.", oldName)
+		}
+
+	Commit()
+}
+
+// Replace the history of a managed database with a single revision holding 
+// its current schema. The old revisions are archived. Returns the revision the 
+// database was at before it was rebaselined.
+func RebaselineDatabase(database string, comment string) (uint64) {
+	AssertHasOwnHistory(database)
+	assertHoldsNoGroupHistory(database, "rebaseline")
+	AssertNoPendingRevision(database)
+	AssertNoInterruptedUpdate(database)
+
+	head := GetHeadRevision(database)
+	if current := GetCurrentSchemaRevision(database); current != head {
+		log.Fatalf("Database '%s' is at revision '%d' not the latest revision '%d', update it before rebaselining.\n", database, current, head)
+	}
+
+	fullSql        := GenerateSchema(database)
+	encoding, data := newSnapshot(database, 1, fullSql)
+	databaseId     := getDatabaseId(database)
+
+	StartTransaction()
+
+		archiveRevisions(database, databaseId, ARCHIVE_REBASELINE)
+
+		err := Exec(configSql("DELETE FROM snap_config.revisions WHERE databaseId = ?;"), databaseId)
+		exitOnError(err, "Error occurred removing the revisions of database '%s'.", database)
+
+		query := configSql(`INSERT INTO snap_config.revisions
+			(databaseId, revision, upSql, downSql, fullSql, fullSqlEncoding, comment, author)
+			VALUES (?, 1, NULL, NULL, ?, ?, ?, ?);`)
+
+		_, err = InsertRow(query, databaseId, data, encoding, comment, config.GetConfig().Identity)
+		exitOnError(err, "Error occurred creating the new first revision of database '%s'.", database)
+
+		err = Exec(configSql("UPDATE snap_config.initialisedDatabases SET currentSchemaRevision = 1 WHERE id = ?;"), databaseId)
+		exitOnError(err, "Error occurred updating the current revision of database '%s'.", database)
+
+	Commit()
+	return head
+}
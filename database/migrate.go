@@ -0,0 +1,206 @@
+package database
+
+// Imports.
+import "bufio"
+import "fmt"
+import "log"
+import "os"
+import "strings"
+
+// The SQL to create the table recording the version of the config database 
+// schema. The table is created by the first upgrade so a config database 
+// without it is at version zero.
+const configVersionsTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.configVersions (
+  version INT UNSIGNED NOT NULL,
+  description VARCHAR(255) NOT NULL,
+  dateApplied TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (version))
+ENGINE = InnoDB;`
+
+// A step upgrading the config database schema to a version. MySql implicitly 
+// commits DDL, so a step can't be rolled back if a later one fails. Each step 
+// is therefore written so it can be applied again, and the version is 
+// recorded as soon as it succeeds so an interrupted upgrade resumes from the 
+// step that failed.
+type configMigration struct {
+	Version uint64
+	Description string
+	Apply func() (error)
+}
+
+// The steps upgrading the config database schema in the order they're applied. 
+// New steps must only ever be appended with the next version number and the 
+// schema created by CreateConfigDatabase must match the result of applying 
+// all of them. The SQL creating a table added by a step is shared with 
+// CreateConfigDatabase so both create the same table.
+var configMigrations = []configMigration{
+	{1, "Add the object filters table.", execConfigSql(objectFiltersTableSql)},
+	{2, "Add the update progress table.", execConfigSql(updateProgressTableSql)},
+	{3, "Add the backups table.", execConfigSql(backupsTableSql)},
+	{4, "Add the temporary databases table.", execConfigSql(tempDatabasesTableSql)},
+	{5, "Add the status column to the revisions table.", addConfigColumn("revisions", "status", revisionStatusColumnSql)},
+	{6, "Add the history database column to the initialised databases table.", addConfigColumn("initialisedDatabases", "historyDatabaseId", historyDatabaseColumnSql)},
+	{7, "Add the database groups table.", execConfigSql(databaseGroupsTableSql)},
+	{8, "Add the archived revisions table.", execConfigSql(archivedRevisionsTableSql)},
+	{9, "Remove the 64KB limit on the SQL stored for revisions.", execConfigSql(revisionSqlColumnsSql)},
+	{10, "Add the snapshot encoding column to the revisions table.", addConfigColumn("revisions", "fullSqlEncoding", revisionEncodingColumnSql)},
+	{11, "Remove the 64KB limit on the SQL stored for archived revisions.", execConfigSql(archivedRevisionsSqlColumnsSql)},
+	{12, "Add the snapshot encoding column to the archived revisions table.", addConfigColumn("archivedRevisions", "fullSqlEncoding", archivedRevisionsEncodingColumnSql)},
+	{13, "Compress the full SQL snapshots of existing revisions.", compressTextSnapshots},
+}
+
+// Whether the config database is upgraded without asking first.
+var upgradeWithoutAsking bool = false
+
+// Enable or disable upgrading the config database without asking first, e.g. 
+// when snap is run by a script.
+func SetUpgradeWithoutAsking(enabled bool) {
+	upgradeWithoutAsking = enabled
+}
+
+// Return a step executing the passed SQL.
+func execConfigSql(sql string) (func() (error)) {
+	return func() (error) {
+		return ExecUnsafe(configSql(sql))
+	}
+}
+
+// Return a step executing the passed SQL to add a column to a table of the 
+// config database, unless the column already exists.
+func addConfigColumn(table string, column string, sql string) (func() (error)) {
+	return func() (error) {
+		if configColumnExists(table, column) {
+			return nil
+		}
+		return ExecUnsafe(configSql(sql))
+	}
+}
+
+// Compress the full SQL snapshots of revisions stored as text by an earlier 
+// version of snap. Each snapshot is compressed on its own so an interrupted 
+// upgrade carries on with the snapshots still held as text.
+func compressTextSnapshots() (error) {
+	query := configSql(`SELECT id, fullSql
+		FROM snap_config.revisions
+		WHERE fullSqlEncoding = 'text'
+		AND status = 'complete';`)
+
+	rows, err := Query(query)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		data, err := encodeSnapshot(SNAPSHOT_COMPRESSED, row.Str(1), "")
+		if err != nil {
+			return err
+		}
+		err = Exec(configSql("UPDATE snap_config.revisions SET fullSql = ?, fullSqlEncoding = 'compressed' WHERE id = ?;"), data, row.Uint64(0))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check if a column exists in a table of the snap config database.
+func configColumnExists(table string, column string) (bool) {
+	query := `SELECT COLUMN_NAME
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		AND TABLE_NAME = ?
+		AND COLUMN_NAME = ?
+		LIMIT 1;`
+	row, err := QueryRow(query, configDatabaseName(), table, column)
+	exitOnError(err, "Can not access column information for the snap config database.")
+	return len(row) > 0
+}
+
+// Return the version of the schema created by this version of snap.
+func latestConfigVersion() (uint64) {
+	return configMigrations[len(configMigrations) - 1].Version
+}
+
+// Get the version of the config database schema. A config database created 
+// before versions were recorded is at version zero.
+func getConfigVersion() (uint64) {
+	query := `SELECT TABLE_NAME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?
+		AND TABLE_NAME = 'configVersions'
+		LIMIT 1;`
+	row, err := QueryRow(query, configDatabaseName())
+	exitOnError(err, "Can not access table information for the snap config database.")
+	if len(row) == 0 {
+		return 0
+	}
+
+	row, err = QueryRow(configSql("SELECT COALESCE(MAX(version), 0) FROM snap_config.configVersions;"))
+	exitOnError(err, "Can not retrieve the version of the snap config database.")
+	return row.Uint64(0)
+}
+
+// Record that the config database schema has reached a version.
+func recordConfigVersion(version uint64, description string) {
+	query := configSql(`INSERT INTO snap_config.configVersions
+		(version, description)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE
+		description = VALUES(description),
+		dateApplied = CURRENT_TIMESTAMP;`)
+
+	err := Exec(query, version, description)
+	exitOnError(err, "Error occurred recording version '%d' of the snap config database.", version)
+}
+
+// Upgrade a snap config database created by an earlier version of snap. The 
+// pending steps are listed and the user is asked before they're applied, 
+// unless upgrading without asking has been enabled. If snap isn't run from a 
+// terminal it can't ask, in which case a fatal error is thrown.
+func upgradeConfigDatabase() {
+	version := getConfigVersion()
+	latest  := latestConfigVersion()
+
+	if version > latest {
+		log.Fatalf("The snap config database is at version '%d' which is newer than this version of snap supports, upgrade snap to use it.\n", version)
+	}
+	if version == latest {
+		return
+	}
+
+	pending := make([]configMigration, 0)
+	for _, migration := range configMigrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+
+	log.Printf("The snap config database must be upgraded from version '%d' to '%d':\n", version, latest)
+	for _, migration := range pending {
+		log.Printf("    %d. %s\n", migration.Version, migration.Description)
+	}
+	if !upgradeWithoutAsking && !confirmUpgrade() {
+		log.Fatalln("Snap can not be used until the snap config database is upgraded.")
+	}
+
+	err := ExecUnsafe(configSql(configVersionsTableSql))
+	exitOnError(err, "Snap config database versions table creation failed.")
+
+	for _, migration := range pending {
+		err = migration.Apply()
+		exitOnError(err, "Upgrading the snap config database to version '%d' failed.", migration.Version)
+		recordConfigVersion(migration.Version, migration.Description)
+	}
+	log.Println("Snap config database upgraded successfully.")
+}
+
+// Ask the user to confirm the config database should be upgraded.
+func confirmUpgrade() (bool) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode() & os.ModeCharDevice == 0 {
+		log.Println("Snap is not being run from a terminal so can not ask to upgrade, use the --upgrade option to upgrade without asking.")
+		return false
+	}
+	fmt.Print("Upgrade the snap config database now? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer      = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,164 @@
+package database
+
+// Imports.
+import "github.com/nomad-software/snap/sanitise"
+import "log"
+
+// Directions a revision can be applied in.
+const UP_DIRECTION string = "up"
+const DOWN_DIRECTION string = "down"
+
+// The SQL to create the update progress table.
+const updateProgressTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.updateProgress (
+  databaseId INT UNSIGNED NOT NULL,
+  targetRevision INT UNSIGNED NOT NULL,
+  revision INT UNSIGNED NOT NULL,
+  direction ENUM('up', 'down') NOT NULL,
+  statementIndex INT UNSIGNED NOT NULL,
+  error TEXT NULL DEFAULT NULL,
+  dateUpdated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (databaseId),
+  CONSTRAINT fk_updateProgress_initialisedDatabases
+    FOREIGN KEY (databaseId)
+    REFERENCES snap_config.initialisedDatabases (id)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;`
+
+// The progress of an interrupted update.
+type updateProgress struct {
+	Target uint64
+	Revision uint64
+	Direction string
+	Statement uint64
+	Error string
+}
+
+// Apply the statements of a revision's SQL one at a time, starting from the 
+// passed statement. The progress is recorded before each statement is applied. 
+// If a statement fails the error is recorded with the progress and a fatal 
+// error is thrown, leaving the progress as the point to resume from. Any tables 
+// whose data could be lost are backed up before the first statement.
+func applyRevisionStatements(database string, target uint64, revision uint64, direction string, sql string, start uint64) {
+	statements := splitExecutableStatements(sql)
+	if start == 0 {
+		backupDestructiveTables(database, revision, direction, sql)
+	}
+	for index := start; index < uint64(len(statements)); index++ {
+		saveUpdateProgress(database, target, revision, direction, index, "")
+		assertUseDatabase(database)
+		err := ExecMulti(statements[index])
+		if err != nil {
+			saveUpdateProgress(database, target, revision, direction, index, err.Error())
+			log.Println(err)
+			log.Printf("Error occurred applying statement %d of the %s SQL of revision '%d' to database '%s':\n\n%s\n\n", index + 1, direction, revision, database, statements[index])
+			log.Fatalf("Fix the cause and run 'snap update --resume %s' to continue.\n", database)
+		}
+	}
+}
+
+// Split SQL into the statements to execute, ignoring any that are empty.
+func splitExecutableStatements(sql string) ([]string) {
+	statements := make([]string, 0)
+	for _, statement := range sanitise.SplitStatements(sanitise.SanitiseSql(sql)) {
+		if !statement.IsEmpty() {
+			statements = append(statements, statement.Sql())
+		}
+	}
+	return statements
+}
+
+// Record the progress of an update.
+func saveUpdateProgress(database string, target uint64, revision uint64, direction string, statement uint64, message string) {
+	databaseId := getDatabaseId(database)
+
+	query := configSql(`INSERT INTO snap_config.updateProgress
+		(databaseId, targetRevision, revision, direction, statementIndex, error)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON DUPLICATE KEY UPDATE
+		targetRevision = VALUES(targetRevision),
+		revision = VALUES(revision),
+		direction = VALUES(direction),
+		statementIndex = VALUES(statementIndex),
+		error = VALUES(error);`)
+
+	err := Exec(query, databaseId, target, revision, direction, statement, message)
+	exitOnError(err, "Error occurred while recording update progress for database '%s'.", database)
+}
+
+// Remove the recorded progress of an update once it has finished.
+func clearUpdateProgress(database string) {
+	databaseId := getDatabaseId(database)
+
+	err := Exec(configSql("DELETE FROM snap_config.updateProgress WHERE databaseId = ?;"), databaseId)
+	exitOnError(err, "Error occurred while clearing update progress for database '%s'.", database)
+}
+
+// Get the progress of an interrupted update of a managed database. The second 
+// return value is false if there is no interrupted update.
+func getUpdateProgress(database string) (progress updateProgress, found bool) {
+
+	assertDatabaseIsManaged(database)
+
+	query := configSql(`SELECT
+		up.targetRevision,
+		up.revision,
+		up.direction,
+		up.statementIndex,
+		COALESCE(up.error, '')
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.updateProgress AS up ON up.databaseId = id.id
+		WHERE id.name = ?
+		LIMIT 1;`)
+
+	row, err := QueryRow(query, database)
+	exitOnError(err, "Can not retrieve update progress for database '%s'.", database)
+
+	if len(row) > 0 {
+		progress = updateProgress{row.Uint64(0), row.Uint64(1), row.Str(2), row.Uint64(3), row.Str(4)}
+		found    = true
+	}
+	return
+}
+
+// Assert that a managed database has no interrupted update. If it has throw a 
+// fatal error.
+func AssertNoInterruptedUpdate(database string) {
+	if progress, found := getUpdateProgress(database); found {
+		log.Printf("An update of database '%s' was interrupted at statement %d of the %s SQL of revision '%d'.\n", database, progress.Statement + 1, progress.Direction, progress.Revision)
+		log.Fatalf("Run 'snap update --resume %s' to continue it.\n", database)
+	}
+}
+
+// Resume an interrupted update of a managed database from the statement that 
+// failed. If requested the failed statement is skipped, for example if it has 
+// been applied by hand. Returns false if there is no interrupted update.
+func ResumeUpdate(database string, skip bool) (bool) {
+	progress, found := getUpdateProgress(database)
+	if !found {
+		return false
+	}
+
+	statement := progress.Statement
+	if skip {
+		statement++
+	}
+
+	revision := progress.Revision
+	if progress.Direction == UP_DIRECTION {
+		forwardSchema(database, progress.Target, revision, statement)
+		for revision < progress.Target {
+			revision++
+			forwardSchema(database, progress.Target, revision, 0)
+		}
+	} else {
+		reverseSchema(database, progress.Target, revision, statement)
+		revision--
+		for revision > progress.Target {
+			reverseSchema(database, progress.Target, revision, 0)
+			revision--
+		}
+	}
+	clearUpdateProgress(database)
+	return true
+}
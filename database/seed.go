@@ -0,0 +1,183 @@
+package database
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/sanitise"
+import "sort"
+import "strings"
+
+// Steps of validating a schema update at which rows are counted.
+const SEEDED_STEP int = 0
+const UP_STEP int = 1
+const DOWN_STEP int = 2
+const REAPPLIED_STEP int = 3
+
+// The value used for the row count of a table that doesn't exist.
+const MISSING_TABLE int64 = -1
+
+// Options for seeding the temporary database used to validate a schema update.
+type SeedOptions struct {
+	SampleRows uint64
+	FixtureFile string
+}
+
+// Check if any seeding has been requested.
+func (this SeedOptions) Enabled() (bool) {
+	return this.SampleRows > 0 || this.FixtureFile != ""
+}
+
+// The row counts of a table after each step of validating a schema update.
+type tableRowCount struct {
+	Table string
+	Counts [4]int64
+}
+
+// A collection of table row counts.
+type tableRowCountList []*tableRowCount
+
+// Return a new list of table row counts.
+func newTableRowCountList() (tableRowCountList) {
+	return make(tableRowCountList, 0)
+}
+
+// Record the row counts of all tables after a step. Tables that didn't exist 
+// after earlier steps are added to the list.
+func (this *tableRowCountList) record(step int, rows map[string]int64) {
+	for table := range rows {
+		if this.find(table) == nil {
+			entry := &tableRowCount{Table: table}
+			for index := range entry.Counts {
+				entry.Counts[index] = MISSING_TABLE
+			}
+			*this = append(*this, entry)
+		}
+	}
+	sort.Sort(*this)
+	for _, entry := range *this {
+		if count, ok := rows[entry.Table]; ok {
+			entry.Counts[step] = count
+		} else {
+			entry.Counts[step] = MISSING_TABLE
+		}
+	}
+}
+
+// Find the row counts of the named table.
+func (this tableRowCountList) find(table string) (*tableRowCount) {
+	for _, entry := range this {
+		if entry.Table == table {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Sort interface implementation.
+func (this tableRowCountList) Len() (int) {
+	return len(this)
+}
+
+// Sort interface implementation.
+func (this tableRowCountList) Less(i int, j int) (bool) {
+	return this[i].Table < this[j].Table
+}
+
+// Sort interface implementation.
+func (this tableRowCountList) Swap(i int, j int) {
+	this[i], this[j] = this[j], this[i]
+}
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this tableRowCount) TabbedString() (string) {
+	output := []string{this.Table}
+	for step := range this.Counts {
+		if this.Counts[step] == MISSING_TABLE {
+			output = append(output, "-")
+		} else {
+			output = append(output, fmt.Sprintf("%d", this.Counts[step]))
+		}
+	}
+	return strings.Join(output, "\t")
+}
+
+// A change between two steps of validating a schema update.
+type stepChange struct {
+	from int
+	to int
+	description string
+}
+
+// Return warnings about any data lost by the table during validation.
+func (this tableRowCount) Warnings() ([]string) {
+	warnings := make([]string, 0)
+	steps    := []stepChange{
+		{SEEDED_STEP, UP_STEP, "applying the update SQL"},
+		{UP_STEP, DOWN_STEP, "applying the down SQL"},
+		{DOWN_STEP, REAPPLIED_STEP, "applying the update SQL again"},
+	}
+	for _, step := range steps {
+		before := this.Counts[step.from]
+		after  := this.Counts[step.to]
+		if before <= 0 {
+			continue
+		}
+		if after == MISSING_TABLE {
+			warnings = append(warnings, fmt.Sprintf("Table '%s' containing %d rows was dropped by %s.", this.Table, before, step.description))
+		} else if after < before {
+			warnings = append(warnings, fmt.Sprintf("Table '%s' lost %d of %d rows by %s.", this.Table, before - after, before, step.description))
+		}
+	}
+	return warnings
+}
+
+// Seed a temporary copy of a managed database with data. A sample of rows is 
+// copied from each table of the managed database and then any fixture file is 
+// applied. Foreign key checks are disabled while seeding as the sampled rows 
+// are unlikely to satisfy them. Any triggers in the temporary database will 
+// fire as rows are inserted.
+func seedTempDatabase(temp string, database string, seed SeedOptions, filters objectFilters) {
+	if !seed.Enabled() {
+		return
+	}
+
+	assertUseDatabase(temp)
+	err := ExecUnsafe("SET FOREIGN_KEY_CHECKS = 0;")
+	exitOnError(err, "Error occurred disabling foreign key checks.")
+
+	if seed.SampleRows > 0 {
+		for _, table := range getAllTableNames(temp, filters) {
+			copySampleRows(database, temp, table, seed.SampleRows)
+		}
+	}
+
+	if seed.FixtureFile != "" {
+		sql := sanitise.ReadFile(seed.FixtureFile)
+		err  = applySqlToTempDatabase(temp, database, sql)
+		exitOnError(err, "Error occurred applying fixture file '%s'.", seed.FixtureFile)
+	}
+
+	err = ExecUnsafe("SET FOREIGN_KEY_CHECKS = 1;")
+	exitOnError(err, "Error occurred enabling foreign key checks.")
+}
+
+// Copy a sample of rows from a table in the source database to the same table 
+// in the destination database. Generated columns are skipped as they can't be 
+// inserted into.
+func copySampleRows(source string, destination string, table string, rows uint64) {
+	columnList := strings.Join(getInsertableColumns(destination, table), ", ")
+
+	err := ExecUnsafe("INSERT INTO `%s`.`%s` (%s) SELECT %s FROM `%s`.`%s` LIMIT %d;", destination, table, columnList, columnList, source, table, rows)
+	exitOnError(err, "Error occurred copying sample rows of table '%s'.", table)
+}
+
+// Count the rows of every table in a database.
+func countRows(databaseName string, filters objectFilters) (rows map[string]int64) {
+	assertUseDatabase(databaseName)
+	rows = make(map[string]int64)
+	for _, table := range getAllTableNames(databaseName, filters) {
+		row, err := QueryRowUnsafe("SELECT COUNT(*) FROM `%s`.`%s`;", databaseName, table)
+		exitOnError(err, "Can not count the rows of table '%s'.", table)
+		rows[table] = row.Int64(0)
+	}
+	return
+}
@@ -0,0 +1,286 @@
+package database
+
+// Imports.
+import "bytes"
+import "compress/zlib"
+import "fmt"
+import "github.com/nomad-software/snap/config"
+import "io/ioutil"
+import "strconv"
+import "strings"
+
+// Ways the full SQL snapshot of a revision can be stored. Snapshots stored by 
+// earlier versions of snap are held as text until the config database is 
+// upgraded. A delta is stored against the snapshot of the previous revision.
+const SNAPSHOT_TEXT string = "text"
+const SNAPSHOT_COMPRESSED string = "compressed"
+const SNAPSHOT_DELTA string = "delta"
+
+// When snapshots are stored as deltas every revision that is a multiple of this 
+// interval is still stored in full, so rebuilding any revision never needs more 
+// deltas applying than this.
+const SNAPSHOT_KEYFRAME_INTERVAL uint64 = 25
+
+// Prefixes of the lines of a delta. A copy line copies a number of lines from 
+// the previous snapshot and an insert line holds a line that is new.
+const DELTA_COPY string = "="
+const DELTA_INSERT string = "+"
+
+// Encode the full SQL snapshot of a new revision of a database, returning how 
+// it was stored and the stored data. Snapshots are compressed and, if enabled 
+// for the database, stored as deltas between keyframes.
+func newSnapshot(database string, revision uint64, fullSql string) (string, []byte) {
+	encoding := SNAPSHOT_COMPRESSED
+	previous := ""
+
+	if revision > 1 && revision % SNAPSHOT_KEYFRAME_INTERVAL != 0 && config.GetConfig().DeltaSnapshots(database) {
+		encoding = SNAPSHOT_DELTA
+		previous = GetSchema(database, revision - 1)
+	}
+
+	data, err := encodeSnapshot(encoding, fullSql, previous)
+	exitOnError(err, "Error occurred storing the full SQL for database '%s' at revision '%d'.", database, revision)
+	return encoding, data
+}
+
+// Encode a full SQL snapshot. The previous snapshot is only used by deltas.
+func encodeSnapshot(encoding string, fullSql string, previous string) ([]byte, error) {
+	switch encoding {
+		case SNAPSHOT_TEXT:
+			return []byte(fullSql), nil
+
+		case SNAPSHOT_COMPRESSED:
+			return compress(fullSql)
+
+		case SNAPSHOT_DELTA:
+			return compress(makeDelta(previous, fullSql))
+	}
+	return nil, fmt.Errorf("Unknown snapshot encoding '%s'.", encoding)
+}
+
+// Decode a stored full SQL snapshot. The previous snapshot is only used by 
+// deltas.
+func decodeSnapshot(encoding string, data string, previous string) (string, error) {
+	switch encoding {
+		case SNAPSHOT_TEXT:
+			return data, nil
+
+		case SNAPSHOT_COMPRESSED:
+			return decompress(data)
+
+		case SNAPSHOT_DELTA:
+			delta, err := decompress(data)
+			if err != nil {
+				return "", err
+			}
+			return applyDelta(previous, delta)
+	}
+	return "", fmt.Errorf("Unknown snapshot encoding '%s'.", encoding)
+}
+
+// Compress text.
+func compress(text string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Decompress text.
+func decompress(data string) (string, error) {
+	reader, err := zlib.NewReader(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	text, err := ioutil.ReadAll(reader)
+	return string(text), err
+}
+
+// Make a line based delta that rebuilds the target from the base. Runs of lines 
+// found in the base are copied from it, preferring to carry on from the end of 
+// the last run so unchanged stretches of schema become a single copy.
+func makeDelta(base string, target string) (string) {
+	baseLines   := strings.Split(base, "\n")
+	targetLines := strings.Split(target, "\n")
+
+	positions := make(map[string][]int)
+	for index, line := range baseLines {
+		positions[line] = append(positions[line], index)
+	}
+
+	delta := make([]string, 0)
+	next  := 0
+	for index := 0; index < len(targetLines); {
+		start, length := -1, 0
+		for _, position := range positions[targetLines[index]] {
+			run := matchingLines(baseLines[position:], targetLines[index:])
+			if run > length || (run == length && position == next) {
+				start, length = position, run
+			}
+		}
+		if length == 0 {
+			delta  = append(delta, DELTA_INSERT + targetLines[index])
+			index += 1
+			continue
+		}
+		delta  = append(delta, fmt.Sprintf("%s%d,%d", DELTA_COPY, start, length))
+		index += length
+		next   = start + length
+	}
+	return strings.Join(delta, "\n")
+}
+
+// Count the lines at the start of two slices that match.
+func matchingLines(base []string, target []string) (count int) {
+	for count < len(base) && count < len(target) && base[count] == target[count] {
+		count++
+	}
+	return
+}
+
+// Rebuild the target of a delta from its base.
+func applyDelta(base string, delta string) (string, error) {
+	baseLines := strings.Split(base, "\n")
+	lines     := make([]string, 0, len(baseLines))
+
+	for _, line := range strings.Split(delta, "\n") {
+		if strings.HasPrefix(line, DELTA_INSERT) {
+			lines = append(lines, strings.TrimPrefix(line, DELTA_INSERT))
+			continue
+		}
+		fields := strings.Split(strings.TrimPrefix(line, DELTA_COPY), ",")
+		if !strings.HasPrefix(line, DELTA_COPY) || len(fields) != 2 {
+			return "", fmt.Errorf("Invalid delta line '%s'.", line)
+		}
+		start, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return "", err
+		}
+		length, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "", err
+		}
+		if start < 0 || length < 0 || start + length > len(baseLines) {
+			return "", fmt.Errorf("Delta copies lines outside of the previous snapshot.")
+		}
+		lines = append(lines, baseLines[start:start + length]...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Decodes the snapshots of consecutive revisions, oldest first, remembering 
+// the last one as the base of any delta that follows.
+type snapshotDecoder struct {
+	Revision uint64
+	FullSql string
+}
+
+// Decode the stored snapshot of the next revision.
+func (this *snapshotDecoder) decode(revision uint64, encoding string, data string) (string, error) {
+	if encoding == SNAPSHOT_DELTA && (this.Revision == 0 || this.Revision != revision - 1) {
+		return "", fmt.Errorf("Revision '%d' is stored as a delta but the previous revision was not read.", revision)
+	}
+	fullSql, err := decodeSnapshot(encoding, data, this.FullSql)
+	if err != nil {
+		return "", err
+	}
+	this.Revision = revision
+	this.FullSql  = fullSql
+	return fullSql, nil
+}
+
+// Get the full SQL of the passed database at each revision between two 
+// revisions inclusive, keyed by revision. A zero revision leaves that end of 
+// the range open. Deltas are rebuilt from the nearest full snapshot at or 
+// before the first revision.
+func getSchemas(database string, from uint64, to uint64) (map[uint64]string) {
+
+	assertDatabaseIsManaged(database)
+
+	conditions := []string{"id.name = ?"}
+	params     := []interface{}{database}
+
+	if from > 0 {
+		conditions = append(conditions, configSql(`r.revision >= COALESCE((SELECT MAX(k.revision)
+			FROM snap_config.revisions AS k
+			WHERE k.databaseId = r.databaseId
+			AND k.revision <= ?
+			AND k.status = 'complete'
+			AND k.fullSqlEncoding <> 'delta'), ?)`))
+		params = append(params, from, from)
+	}
+	if to > 0 {
+		conditions = append(conditions, "r.revision <= ?")
+		params     = append(params, to)
+	}
+
+	query := configSql(fmt.Sprintf(`SELECT
+		r.revision,
+		r.fullSqlEncoding,
+		r.fullSql
+		FROM snap_config.initialisedDatabases AS id
+		INNER JOIN snap_config.revisions AS r ON r.databaseId = COALESCE(id.historyDatabaseId, id.id) AND r.status = 'complete'
+		WHERE %s
+		ORDER BY r.revision ASC;`, strings.Join(conditions, "\n\t\tAND ")))
+
+	rows, err := Query(query, params...)
+	exitOnError(err, "Can not retrieve full SQL for database '%s'.", database)
+
+	schemas := make(map[uint64]string)
+	decoder := snapshotDecoder{}
+	for _, row := range rows {
+		revision     := row.Uint64(0)
+		fullSql, err := decoder.decode(revision, row.Str(1), row.Str(2))
+		exitOnError(err, "Can not rebuild full SQL for database '%s' at revision '%d'.", database, revision)
+		if revision >= from {
+			schemas[revision] = fullSql
+		}
+	}
+	return schemas
+}
+
+// Replace text in the full SQL snapshot of every revision held by a database. 
+// Each snapshot is stored again the same way it was before.
+func replaceInSnapshots(databaseId uint64, old string, new string) (error) {
+	query := configSql(`SELECT
+		r.id,
+		r.revision,
+		r.fullSqlEncoding,
+		r.fullSql
+		FROM snap_config.revisions AS r
+		WHERE r.databaseId = ?
+		AND r.status = 'complete'
+		ORDER BY r.revision ASC;`)
+
+	rows, err := Query(query, databaseId)
+	if err != nil {
+		return err
+	}
+
+	decoder  := snapshotDecoder{}
+	previous := ""
+	for _, row := range rows {
+		encoding     := row.Str(2)
+		fullSql, err := decoder.decode(row.Uint64(1), encoding, row.Str(3))
+		if err != nil {
+			return err
+		}
+		fullSql    = strings.Replace(fullSql, old, new, -1)
+		data, err := encodeSnapshot(encoding, fullSql, previous)
+		if err != nil {
+			return err
+		}
+		err = Exec(configSql("UPDATE snap_config.revisions SET fullSql = ? WHERE id = ?;"), data, row.Uint64(0))
+		if err != nil {
+			return err
+		}
+		previous = fullSql
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package database
+
+// The status of a managed database.
+type databaseStatus struct {
+	CurrentRevision uint64
+	HeadRevision uint64
+	PendingRevision uint64
+	Interrupted bool
+	InterruptedRevision uint64
+	InterruptedDirection string
+	InterruptedStatement uint64
+	InterruptedError string
+}
+
+// Get the status of a managed database. This includes its current and latest 
+// revisions, any revision left pending by an interrupted commit and the 
+// progress of any interrupted update.
+func GetDatabaseStatus(database string) (status databaseStatus) {
+	status.CurrentRevision = GetCurrentSchemaRevision(database)
+	status.HeadRevision    = GetHeadRevision(database)
+
+	if pending, found := getPendingRevision(database); found {
+		status.PendingRevision = pending.Revision
+	}
+
+	if progress, found := getUpdateProgress(database); found {
+		status.Interrupted          = true
+		status.InterruptedRevision  = progress.Revision
+		status.InterruptedDirection = progress.Direction
+		status.InterruptedStatement = progress.Statement + 1
+		status.InterruptedError     = progress.Error
+	}
+	return
+}
@@ -0,0 +1,256 @@
+package database
+
+// Imports.
+import "crypto/rand"
+import "fmt"
+import "github.com/nomad-software/snap/config"
+import "log"
+import "os"
+import "os/signal"
+import "regexp"
+import "sync"
+import "syscall"
+import "time"
+
+// States of a temporary database found when collecting garbage.
+const TEMP_IN_USE string = "in use"
+const TEMP_RECENT string = "recent"
+const TEMP_ORPHANED string = "orphaned"
+const TEMP_UNCONFIRMED string = "unconfirmed"
+
+// The SQL to create the temporary databases table.
+const tempDatabasesTableSql string = `CREATE TABLE IF NOT EXISTS snap_config.tempDatabases (
+  name VARCHAR(64) NOT NULL,
+  owner VARCHAR(255) NOT NULL,
+  host VARCHAR(255) NOT NULL,
+  processId INT UNSIGNED NOT NULL,
+  dateCreated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (name))
+ENGINE = InnoDB;`
+
+// The temporary databases created by this process.
+var tempDatabases []string = make([]string, 0)
+var tempDatabasesLock sync.Mutex
+
+// Matches the names of temporary databases.
+var tempDatabaseName = regexp.MustCompile(`^snap_[0-9A-F]{8}$`)
+
+// Generate a random name for a temporary database. The name is registered in 
+// the config database along with the owner, host and process creating it, so 
+// it's not mistaken for an orphan by another run of snap.
+func generateTempDatabaseName() (string) {
+	bytes := make([]byte, 4)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		log.Fatalln("Error occurred generating a temporary database name.")
+	}
+	name := fmt.Sprintf("snap_%X", bytes)
+
+	query := configSql(`INSERT INTO snap_config.tempDatabases
+		(name, owner, host, processId)
+		VALUES (?, ?, ?, ?);`)
+
+	err = Exec(query, name, config.GetConfig().Identity, getHostName(), os.Getpid())
+	exitOnError(err, "Error occurred registering temporary database '%s'.", name)
+
+	// Record the name to drop later to clean up.
+	tempDatabasesLock.Lock()
+	tempDatabases = append(tempDatabases, name)
+	tempDatabasesLock.Unlock()
+	return name;
+}
+
+// Delete any temporary database that have been created and remove their 
+// registrations. Errors are ignored as this is called while handling errors.
+func deleteTempDatabases() {
+	tempDatabasesLock.Lock()
+	defer tempDatabasesLock.Unlock()
+	for _, database := range tempDatabases {
+		_ = dropDatabase(database)
+		_ = Exec(configSql("DELETE FROM snap_config.tempDatabases WHERE name = ?;"), database)
+	}
+	tempDatabases = make([]string, 0)
+}
+
+// Clean up when the program is interrupted or terminated. The connection in use 
+// may be part way through a query so a new connection is used to delete the 
+// temporary databases. Any pending transaction is rolled back by the server 
+// when the program exits and its connection is closed.
+func handleInterrupts() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		received := <-signals
+		log.Printf("Received %s, cleaning up.\n", received)
+		tempDatabasesLock.Lock()
+		if len(tempDatabases) > 0 {
+			conn, err := connect(connectionConfig)
+			if err == nil {
+				for _, database := range tempDatabases {
+					_, _, _ = conn.Query("DROP DATABASE IF EXISTS `%s`;", database)
+					_, _, _ = conn.Query(configSql("DELETE FROM snap_config.tempDatabases WHERE name = '%s';"), conn.Escape(database))
+				}
+				conn.Close()
+			} else {
+				log.Println("Temporary databases could not be deleted, run 'snap gc' to delete them.")
+			}
+		}
+		os.Exit(1)
+	}()
+}
+
+// Return the name of this host.
+func getHostName() (string) {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+// A temporary database found when collecting garbage.
+type tempDatabase struct {
+	Name string
+	Owner string
+	Host string
+	ProcessId uint64
+	Created string
+	Age time.Duration
+	AgeKnown bool
+	Registered bool
+	Exists bool
+	State string
+}
+
+// A collection of temporary databases.
+type tempDatabaseList []*tempDatabase
+
+// Return a tabbed output string for writing using a tabbed writer.
+func (this tempDatabase) TabbedString() (string) {
+	owner   := this.Owner
+	host    := this.Host
+	created := this.Created
+	if owner == "" {
+		owner = "-"
+	}
+	if host == "" {
+		host = "-"
+	}
+	if created == "" {
+		created = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s", this.Name, owner, host, created, this.State)
+}
+
+// Find all temporary databases, whether they exist on the server or are only 
+// registered in the config database, and classify each of them. The age of an 
+// unregistered database is taken from its oldest table.
+func GetTempDatabases(olderThan time.Duration) (list tempDatabaseList) {
+
+	list = make(tempDatabaseList, 0)
+	find := func(name string) (*tempDatabase) {
+		for _, entry := range list {
+			if entry.Name == name {
+				return entry
+			}
+		}
+		entry := &tempDatabase{Name: name}
+		list   = append(list, entry)
+		return entry
+	}
+
+	query := `SELECT SCHEMA_NAME
+		FROM information_schema.SCHEMATA
+		WHERE SCHEMA_NAME LIKE 'snap\_%';`
+
+	rows, err := Query(query)
+	exitOnError(err, "Can not retrieve the list of databases.")
+	for _, row := range rows {
+		if tempDatabaseName.MatchString(row.Str(0)) {
+			find(row.Str(0)).Exists = true
+		}
+	}
+
+	query = configSql(`SELECT name,
+		owner,
+		host,
+		processId,
+		dateCreated,
+		TIMESTAMPDIFF(SECOND, dateCreated, NOW())
+		FROM snap_config.tempDatabases
+		ORDER BY dateCreated ASC;`)
+
+	rows, err = Query(query)
+	exitOnError(err, "Can not retrieve the list of temporary databases.")
+	for _, row := range rows {
+		entry          := find(row.Str(0))
+		entry.Owner     = row.Str(1)
+		entry.Host      = row.Str(2)
+		entry.ProcessId = row.Uint64(3)
+		entry.Created   = row.Str(4)
+		entry.Age        = time.Duration(row.Int64(5)) * time.Second
+		entry.AgeKnown   = true
+		entry.Registered = true
+	}
+
+	host := getHostName()
+	for _, entry := range list {
+		if !entry.AgeKnown && entry.Exists {
+			query := `SELECT MIN(CREATE_TIME),
+				TIMESTAMPDIFF(SECOND, MIN(CREATE_TIME), NOW())
+				FROM information_schema.TABLES
+				WHERE TABLE_SCHEMA = ?;`
+			row, err := QueryRow(query, entry.Name)
+			exitOnError(err, "Can not access table information for database '%s'.", entry.Name)
+			if len(row) > 0 && row[0] != nil {
+				entry.Created  = row.Str(0)
+				entry.Age      = time.Duration(row.Int64(1)) * time.Second
+				entry.AgeKnown = true
+			}
+		}
+		entry.State = entry.classify(host, olderThan)
+	}
+	return
+}
+
+// Classify a temporary database. It's in use if it belongs to a process still 
+// running on this host and recent if it's younger than the passed age, or its 
+// age is unknown. Whether a process is running can only be checked on this 
+// host, so an older database is only orphaned if it was registered here. One 
+// registered on another host, or not registered at all as it may belong to 
+// snap using a different config database, is unconfirmed.
+func (this tempDatabase) classify(host string, olderThan time.Duration) (string) {
+	switch {
+		case this.Registered && this.Host == host && processIsRunning(this.ProcessId):
+			return TEMP_IN_USE
+
+		case !this.AgeKnown || this.Age < olderThan:
+			return TEMP_RECENT
+
+		case this.Registered && this.Host == host:
+			return TEMP_ORPHANED
+	}
+	return TEMP_UNCONFIRMED
+}
+
+// Check if a process is running on this host. If this can't be determined, as 
+// on some systems, the process is assumed not to be running.
+func processIsRunning(processId uint64) (bool) {
+	if processId == 0 {
+		return false
+	}
+	process, err := os.FindProcess(int(processId))
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Drop a temporary database and remove its registration.
+func DropTempDatabase(name string) {
+	err := dropDatabase(name)
+	exitOnError(err, "Can not drop temporary database '%s'.", name)
+
+	err = Exec(configSql("DELETE FROM snap_config.tempDatabases WHERE name = ?;"), name)
+	exitOnError(err, "Error occurred removing the registration of temporary database '%s'.", name)
+}
@@ -0,0 +1,33 @@
+package database
+
+// Imports.
+import "os"
+import "testing"
+import "time"
+
+// Test temporary databases are only orphaned if they were registered by a 
+// process on this host that is no longer running.
+func TestClassifyTempDatabase(t *testing.T) {
+	host    := "this-host"
+	running := uint64(os.Getpid())
+	old     := 48 * time.Hour
+	cases   := []struct {
+		Name string
+		Temp tempDatabase
+		State string
+	}{
+		{"running here", tempDatabase{Host: host, ProcessId: running, Age: old, AgeKnown: true, Registered: true}, TEMP_IN_USE},
+		{"stopped here", tempDatabase{Host: host, Age: old, AgeKnown: true, Registered: true}, TEMP_ORPHANED},
+		{"stopped here recently", tempDatabase{Host: host, Age: time.Minute, AgeKnown: true, Registered: true}, TEMP_RECENT},
+		{"other host", tempDatabase{Host: "other-host", ProcessId: running, Age: old, AgeKnown: true, Registered: true}, TEMP_UNCONFIRMED},
+		{"other host recently", tempDatabase{Host: "other-host", Age: time.Minute, AgeKnown: true, Registered: true}, TEMP_RECENT},
+		{"unregistered", tempDatabase{Age: old, AgeKnown: true, Exists: true}, TEMP_UNCONFIRMED},
+		{"unregistered recently", tempDatabase{Age: time.Minute, AgeKnown: true, Exists: true}, TEMP_RECENT},
+		{"unregistered without tables", tempDatabase{Exists: true}, TEMP_RECENT},
+	}
+	for _, test := range cases {
+		if state := test.Temp.classify(host, 24 * time.Hour); state != test.State {
+			t.Errorf("%s: expected '%s', got '%s'", test.Name, test.State, state)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/sanitise"
+
+// Replay the entire history of a managed database in a temporary database. The 
+// full SQL of revision 1 is applied, followed by the update SQL of every other 
+// revision in order. The down SQL of every revision is then applied in reverse 
+// order. After each step the generated schema is compared against the stored 
+// schema of the revision that should have been reached. The first revision 
+// that fails to apply or diverges from its stored schema is returned along 
+// with an error describing the problem. If the history replays correctly a 
+// nil error is returned.
+func VerifyHistory(database string) (revision uint64, err error) {
+	assertDatabaseIsManaged(database)
+
+	head    := GetHeadRevision(database)
+	filters := GetObjectFilters(database)
+	temp    := generateTempDatabaseName()
+	defer deleteTempDatabases()
+
+	revision = 1
+	CopyDatabase(database, temp, revision)
+	if !schemaMatchesRevision(temp, database, revision, filters) {
+		return revision, fmt.Errorf("The schema created from the full SQL doesn't match the stored schema.")
+	}
+
+	for revision = 2; revision <= head; revision++ {
+		err = applySqlToTempDatabase(temp, database, GetUpdateSql(database, revision))
+		if err != nil {
+			return revision, fmt.Errorf("The update SQL failed to apply: %s", err)
+		}
+		if !schemaMatchesRevision(temp, database, revision, filters) {
+			return revision, fmt.Errorf("The schema after applying the update SQL doesn't match the stored schema.")
+		}
+	}
+
+	for revision = head; revision > 1; revision-- {
+		err = applySqlToTempDatabase(temp, database, GetDownSql(database, revision))
+		if err != nil {
+			return revision, fmt.Errorf("The down SQL failed to apply: %s", err)
+		}
+		if !schemaMatchesRevision(temp, database, revision - 1, filters) {
+			return revision, fmt.Errorf("The schema after applying the down SQL doesn't match the stored schema of revision '%d'.", revision - 1)
+		}
+	}
+
+	return 0, nil
+}
+
+// Apply stored SQL of a managed database to a temporary copy of it. SQL 
+// containing no statements is ignored.
+func applySqlToTempDatabase(temp string, database string, sql string) (error) {
+	sql = sanitise.SanitiseSql(sql)
+	sql = retargetDatabaseReferences(sql, database, temp)
+	if !containsStatements(sql) {
+		return nil
+	}
+	assertUseDatabase(temp)
+	return ExecMulti(sql)
+}
+
+// Check if the passed SQL contains any statements to execute.
+func containsStatements(sql string) (bool) {
+	for _, statement := range sanitise.SplitStatements(sql) {
+		if !statement.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that the schema of a temporary copy of a managed database matches the 
+// stored schema of the passed revision.
+func schemaMatchesRevision(temp string, database string, revision uint64, filters objectFilters) (bool) {
+	stored    := NormaliseSchema(database, GetSchema(database, revision))
+	generated := generateComparableSchema(temp, database, filters)
+	return stored == generated
+}
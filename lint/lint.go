@@ -0,0 +1,129 @@
+package lint
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/config"
+import "github.com/nomad-software/snap/sanitise"
+import "sort"
+import "strings"
+
+// Sections of a snap file.
+const UP_SECTION string = "up"
+const DOWN_SECTION string = "down"
+
+// A single problem found in a snap file.
+type Result struct {
+	Line int
+	Severity string
+	Rule string
+	Message string
+}
+
+// A collection of lint results.
+type Results []Result
+
+// Format the result for display to the user.
+func (this Result) String() (string) {
+	return fmt.Sprintf("%d: %s: %s [%s]", this.Line, this.Severity, this.Message, this.Rule)
+}
+
+// Check if any of the results are errors.
+func (this Results) HasErrors() (bool) {
+	for _, result := range this {
+		if result.Severity == config.LINT_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+// A statement of a snap file along with the section it belongs to.
+type statement struct {
+	sanitise.Statement
+	Section string
+}
+
+// Check the contents of a snap file for common problems. The rules applied and 
+// their severity are taken from the config of the named database. If the 
+// database exists it is also used to check the data affected by the file. 
+// Destructive statements are only allowed in the up section if specified.
+func LintFile(file string, databaseName string, allowDestructive bool) (results Results) {
+	contents   := sanitise.ReadFile(file)
+	statements := splitSections(contents)
+	severities := config.GetConfig().LintRules(databaseName)
+
+	checks := map[string]func([]statement) Results{
+		config.LINT_DESTRUCTIVE: func(statements []statement) Results {
+			if allowDestructive {
+				return nil
+			}
+			return checkDestructive(statements)
+		},
+		config.LINT_DOWN_IF_EXISTS: checkDownIfExists,
+		config.LINT_MIXED_DML: checkMixedDml,
+		config.LINT_COPY_ALGORITHM: func(statements []statement) Results {
+			return checkCopyAlgorithm(statements, databaseName)
+		},
+		config.LINT_PRIMARY_KEY: checkPrimaryKey,
+	}
+
+	results = make(Results, 0)
+	for rule, check := range checks {
+		if severities[rule] == config.LINT_OFF {
+			continue
+		}
+		for _, result := range check(statements) {
+			result.Rule     = rule
+			result.Severity = severities[rule]
+			results         = append(results, result)
+		}
+	}
+	sort.Sort(results)
+	return
+}
+
+// Split the contents of a snap file into statements, recording which section 
+// each one belongs to. Statements before the up section are ignored.
+func splitSections(contents string) ([]statement) {
+	upLine   := 0
+	downLine := 0
+	for index, line := range strings.Split(contents, "\n") {
+		if line == config.UP_SQL_START && upLine == 0 {
+			upLine = index + 1
+		} else if line == config.DOWN_SQL_START && downLine == 0 {
+			downLine = index + 1
+		}
+	}
+	statements := make([]statement, 0)
+	for _, sqlStatement := range sanitise.SplitStatements(contents) {
+		line := sqlStatement.Line()
+		if sqlStatement.IsEmpty() || sqlStatement.IsDelimiterCommand() || line < upLine {
+			continue
+		}
+		section := UP_SECTION
+		if downLine > 0 && line > downLine {
+			section = DOWN_SECTION
+		}
+		statements = append(statements, statement{sqlStatement, section})
+	}
+	return statements
+}
+
+// Sort interface implementation. Results are sorted by line number, then by 
+// rule so the output is always the same.
+func (this Results) Len() (int) {
+	return len(this)
+}
+
+// Sort interface implementation.
+func (this Results) Less(i int, j int) (bool) {
+	if this[i].Line != this[j].Line {
+		return this[i].Line < this[j].Line
+	}
+	return this[i].Rule < this[j].Rule
+}
+
+// Sort interface implementation.
+func (this Results) Swap(i int, j int) {
+	this[i], this[j] = this[j], this[i]
+}
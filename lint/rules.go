@@ -0,0 +1,129 @@
+package lint
+
+// Imports.
+import "fmt"
+import "github.com/nomad-software/snap/database"
+import "strings"
+
+// Statements defining the schema.
+var ddlKeywords = []string{"CREATE", "ALTER", "DROP", "RENAME", "TRUNCATE"}
+
+// Statements modifying data.
+var dmlKeywords = []string{"INSERT", "UPDATE", "DELETE", "REPLACE", "LOAD"}
+
+// Objects that can be dropped using IF EXISTS.
+var droppableObjects = []string{"TABLE", "VIEW", "FUNCTION", "PROCEDURE", "TRIGGER", "EVENT"}
+
+// Check for tables and columns dropped in the up section.
+func checkDestructive(statements []statement) (results Results) {
+	for _, statement := range statements {
+		if statement.Section != UP_SECTION {
+			continue
+		}
+		if statement.StartsWith("DROP", "TABLE") || statement.StartsWith("DROP", "TEMPORARY", "TABLE") {
+			message := fmt.Sprintf("Table '%s' is dropped in the up SQL, use --allow-destructive if this is intended.", statement.ObjectName("TABLE"))
+			results  = append(results, Result{Line: statement.Line(), Message: message})
+		} else if statement.StartsWith("ALTER") {
+			for _, column := range statement.DroppedColumns() {
+				message := fmt.Sprintf("Column '%s' is dropped from table '%s' in the up SQL, use --allow-destructive if this is intended.", column, statement.ObjectName("TABLE"))
+				results  = append(results, Result{Line: statement.Line(), Message: message})
+			}
+		}
+	}
+	return
+}
+
+// Check objects are only dropped if they exist in the down section.
+func checkDownIfExists(statements []statement) (results Results) {
+	for _, statement := range statements {
+		if statement.Section != DOWN_SECTION || !statement.StartsWith("DROP") {
+			continue
+		}
+		words := statement.Words()
+		if len(words) > 2 && words[1] == "TEMPORARY" {
+			words = words[1:]
+		}
+		if len(words) > 1 && containsWord(droppableObjects, words[1]) {
+			if len(words) < 4 || words[2] != "IF" || words[3] != "EXISTS" {
+				object  := words[1][:1] + strings.ToLower(words[1][1:])
+				message := fmt.Sprintf("%s '%s' is dropped in the down SQL without using IF EXISTS.", object, statement.ObjectName(words[1]))
+				results  = append(results, Result{Line: statement.Line(), Message: message})
+			}
+		}
+	}
+	return
+}
+
+// Check that statements modifying data are not mixed with statements defining 
+// the schema in the same section.
+func checkMixedDml(statements []statement) (results Results) {
+	for _, section := range []string{UP_SECTION, DOWN_SECTION} {
+		ddlFound := false
+		var dml *statement
+		for index, statement := range statements {
+			if statement.Section != section {
+				continue
+			}
+			words := statement.Words()
+			if len(words) == 0 {
+				continue
+			}
+			if containsWord(ddlKeywords, words[0]) {
+				ddlFound = true
+			} else if containsWord(dmlKeywords, words[0]) && dml == nil {
+				dml = &statements[index]
+			}
+		}
+		if ddlFound && dml != nil {
+			message := fmt.Sprintf("Data is modified in the %s SQL along with schema changes.", section)
+			results  = append(results, Result{Line: dml.Line(), Message: message})
+		}
+	}
+	return
+}
+
+// Check for non-empty tables altered using the copy algorithm, which locks the 
+// table for writes while it is copied. This check can only be made if the 
+// database exists.
+func checkCopyAlgorithm(statements []statement, databaseName string) (results Results) {
+	if databaseName == "" || !database.DatabaseExists(databaseName) {
+		return
+	}
+	for _, statement := range statements {
+		if !statement.StartsWith("ALTER") || !statement.ContainsWords("ALGORITHM", "COPY") {
+			continue
+		}
+		table := statement.ObjectName("TABLE")
+		if table != "" && database.TableHasRows(databaseName, table) {
+			message := fmt.Sprintf("Table '%s' contains data and will be locked while it is copied by ALGORITHM=COPY.", table)
+			results  = append(results, Result{Line: statement.Line(), Message: message})
+		}
+	}
+	return
+}
+
+// Check that new tables have a primary key. Tables created from another table 
+// or a query are ignored.
+func checkPrimaryKey(statements []statement) (results Results) {
+	for _, statement := range statements {
+		if statement.Section != UP_SECTION || !statement.StartsWith("CREATE", "TABLE") {
+			continue
+		}
+		if statement.ContainsWords("PRIMARY", "KEY") || statement.ContainsWords("LIKE") || statement.ContainsWords("SELECT") {
+			continue
+		}
+		message := fmt.Sprintf("Table '%s' is created without a primary key.", statement.ObjectName("TABLE"))
+		results  = append(results, Result{Line: statement.Line(), Message: message})
+	}
+	return
+}
+
+// Check if a word is in the passed list.
+func containsWord(list []string, word string) (bool) {
+	for _, entry := range list {
+		if entry == word {
+			return true
+		}
+	}
+	return false
+}
@@ -25,17 +25,43 @@ func main() {
 	app.HideHelp    = true
 	app.HideVersion = true
 
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "format", Value: "text", Usage: "Output format of read commands: text, json, yaml or csv."},
+		cli.BoolFlag{Name: "upgrade", Usage: "Upgrade the snap config database without asking."},
+	}
+
+	app.Before = func(ctx *cli.Context) (error) {
+		database.SetUpgradeWithoutAsking(ctx.GlobalBool("upgrade"))
+		return nil
+	}
+
 	app.Commands = []cli.Command{
+		command.Apply,
+		command.Bisect,
+		command.Blame,
 		command.Commit,
+		command.Compare,
 		command.Copy,
 		command.Diff,
 		command.Dump,
+		command.Filter,
+		command.Gc,
+		command.Grep,
+		command.Group,
 		command.Help,
 		command.Init,
+		command.Lint,
 		command.List,
 		command.Log,
+		command.Rebaseline,
+		command.Recover,
+		command.Rename,
+		command.RestoreBackup,
+		command.Serve,
 		command.Show,
+		command.Uninit,
 		command.Update,
+		command.Verify,
 		command.Version,
 	}
 
@@ -0,0 +1,145 @@
+package manifest
+
+// Imports.
+import "encoding/json"
+import "fmt"
+import "gopkg.in/yaml.v2"
+import "io/ioutil"
+import "path/filepath"
+import "strconv"
+import "strings"
+
+// The revision value meaning the latest revision of a database.
+const HEAD_REVISION string = "head"
+
+// A target revision, either a revision number or 'head'.
+type Revision string
+
+// Json unmarshaler implementation. Revisions can be written as numbers as well 
+// as strings.
+func (this *Revision) UnmarshalJSON(data []byte) (error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*this = Revision(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*this = Revision(number.String())
+	return nil
+}
+
+// The target of a single database in a release manifest.
+type Target struct {
+	Database string `json:"database" yaml:"database"`
+	Revision Revision `json:"revision" yaml:"revision"`
+	After []string `json:"after" yaml:"after"`
+}
+
+// Parse the target revision. Zero is returned for the head revision.
+func (this Target) ParseRevision() (uint64, error) {
+	if this.Revision == "" || strings.EqualFold(string(this.Revision), HEAD_REVISION) {
+		return 0, nil
+	}
+	revision, err := strconv.ParseUint(string(this.Revision), 10, 64)
+	if err != nil || revision == 0 {
+		return 0, fmt.Errorf("Database '%s' has an invalid target revision '%s'.", this.Database, this.Revision)
+	}
+	return revision, nil
+}
+
+// A release manifest listing the target revision of several databases and the 
+// order they must be updated in.
+type Manifest struct {
+	Release string `json:"release" yaml:"release"`
+	Databases []Target `json:"databases" yaml:"databases"`
+}
+
+// Read a release manifest from a file. Files with a '.json' extension are 
+// parsed as Json, anything else is parsed as Yaml.
+func ReadFile(file string) (manifest Manifest, err error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(contents, &manifest)
+	} else {
+		err = yaml.Unmarshal(contents, &manifest)
+	}
+	if err != nil {
+		return
+	}
+	err = manifest.validate()
+	return
+}
+
+// Check each database is listed once, has a valid revision and only depends on 
+// other databases in the manifest.
+func (this Manifest) validate() (error) {
+	if len(this.Databases) == 0 {
+		return fmt.Errorf("The manifest does not list any databases.")
+	}
+	listed := make(map[string]bool)
+	for _, target := range this.Databases {
+		if target.Database == "" {
+			return fmt.Errorf("The manifest lists a database without a name.")
+		}
+		if listed[target.Database] {
+			return fmt.Errorf("Database '%s' is listed more than once.", target.Database)
+		}
+		listed[target.Database] = true
+		if _, err := target.ParseRevision(); err != nil {
+			return err
+		}
+	}
+	for _, target := range this.Databases {
+		for _, dependency := range target.After {
+			if !listed[dependency] {
+				return fmt.Errorf("Database '%s' depends on database '%s' which is not listed.", target.Database, dependency)
+			}
+		}
+	}
+	return nil
+}
+
+// Return the targets in the order they must be applied. A database is always 
+// applied after the databases it depends on, otherwise the order of the 
+// manifest is kept.
+func (this Manifest) Order() ([]Target, error) {
+	ordered := make([]Target, 0)
+	applied := make(map[string]bool)
+	for len(ordered) < len(this.Databases) {
+		progressed := false
+		for _, target := range this.Databases {
+			if applied[target.Database] || !dependenciesMet(target, applied) {
+				continue
+			}
+			ordered                  = append(ordered, target)
+			applied[target.Database] = true
+			progressed               = true
+		}
+		if !progressed {
+			waiting := make([]string, 0)
+			for _, target := range this.Databases {
+				if !applied[target.Database] {
+					waiting = append(waiting, target.Database)
+				}
+			}
+			return nil, fmt.Errorf("The dependencies of databases '%s' are circular.", strings.Join(waiting, "', '"))
+		}
+	}
+	return ordered, nil
+}
+
+// Check if all the dependencies of a target have been applied.
+func dependenciesMet(target Target, applied map[string]bool) (bool) {
+	for _, dependency := range target.After {
+		if !applied[dependency] {
+			return false
+		}
+	}
+	return true
+}
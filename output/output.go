@@ -0,0 +1,122 @@
+package output
+
+// Imports.
+import "encoding/csv"
+import "encoding/json"
+import "fmt"
+import "gopkg.in/yaml.v2"
+import "log"
+import "os"
+
+// The version of the structure of machine readable output. This is increased 
+// whenever a field is removed or changes meaning. New fields can be added 
+// without changing it.
+const SCHEMA_VERSION int = 1
+
+// Formats that output can be written in.
+const FORMAT_TEXT string = "text"
+const FORMAT_JSON string = "json"
+const FORMAT_YAML string = "yaml"
+const FORMAT_CSV string = "csv"
+
+// A document that can be written in a machine readable format.
+type Document interface {
+	Kind() (string)
+	CsvHeader() ([]string)
+	CsvRows() ([][]string)
+}
+
+// The envelope every Json and Yaml document is wrapped in, identifying the 
+// version of its structure and what kind of document it is.
+type envelope struct {
+	SchemaVersion int `json:"schemaVersion" yaml:"schemaVersion"`
+	Kind string `json:"kind" yaml:"kind"`
+	Data Document `json:"data" yaml:"data"`
+}
+
+// An error in machine readable output, written in place of the document a 
+// command failed to produce.
+type errorDocument struct {
+	Error string `json:"error" yaml:"error"`
+}
+
+// Document interface implementation.
+func (this errorDocument) Kind() (string) {
+	return "error"
+}
+
+// Document interface implementation.
+func (this errorDocument) CsvHeader() ([]string) {
+	return []string{"error"}
+}
+
+// Document interface implementation.
+func (this errorDocument) CsvRows() ([][]string) {
+	return [][]string{{this.Error}}
+}
+
+// Assert the passed format is known. If not throw a fatal error.
+func AssertValidFormat(format string) {
+	switch format {
+		case FORMAT_TEXT, FORMAT_JSON, FORMAT_YAML, FORMAT_CSV:
+			return
+	}
+	log.Fatalf("Output format '%s' is not recognised, use text, json, yaml or csv.\n", format)
+}
+
+// Check if the passed format is the human readable text format.
+func IsText(format string) (bool) {
+	AssertValidFormat(format)
+	return format == FORMAT_TEXT
+}
+
+// Write a document to stdout in the passed machine readable format.
+func Write(format string, document Document) {
+	wrapped := envelope{SCHEMA_VERSION, document.Kind(), document}
+	switch format {
+		case FORMAT_JSON:
+			bytes, err := EncodeJson(document)
+			exitOnError(err, format)
+			fmt.Println(string(bytes))
+
+		case FORMAT_YAML:
+			bytes, err := yaml.Marshal(wrapped)
+			exitOnError(err, format)
+			fmt.Print(string(bytes))
+
+		case FORMAT_CSV:
+			writer := csv.NewWriter(os.Stdout)
+			err    := writer.Write(document.CsvHeader())
+			exitOnError(err, format)
+			err = writer.WriteAll(document.CsvRows())
+			exitOnError(err, format)
+
+		default:
+			AssertValidFormat(format)
+			log.Fatalf("Output can not be written in the '%s' format.\n", format)
+	}
+}
+
+// Encode a document as Json wrapped in its envelope.
+func EncodeJson(document Document) ([]byte, error) {
+	return json.MarshalIndent(envelope{SCHEMA_VERSION, document.Kind(), document}, "", "    ")
+}
+
+// Halt program execution with an error. In a machine readable format the error 
+// is written as a document so consumers always receive one, otherwise it's 
+// logged as usual.
+func Fail(format string, err error) {
+	if IsText(format) {
+		log.Fatalln(err)
+	}
+	Write(format, errorDocument{err.Error()})
+	os.Exit(1)
+}
+
+// Handle an error writing output.
+func exitOnError(err error, format string) {
+	if err != nil {
+		log.Println(err)
+		log.Fatalf("Error occurred writing output in the '%s' format.\n", format)
+	}
+}
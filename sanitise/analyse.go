@@ -0,0 +1,263 @@
+package sanitise
+
+// Imports.
+import "strings"
+
+// Words following DROP in an ALTER TABLE statement which don't drop a column.
+var nonColumnDrops = []string{"INDEX", "KEY", "PRIMARY", "FOREIGN", "CONSTRAINT", "CHECK", "PARTITION", "DEFAULT"}
+
+// Check if the statement contains the passed words in sequence.
+func (this Statement) ContainsWords(sequence ...string) (bool) {
+	words := this.Words()
+	for index := 0; index + len(sequence) <= len(words); index++ {
+		found := true
+		for offset, word := range sequence {
+			if words[index + offset] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the unquoted name of the object following the first occurrence of the 
+// passed keyword. Any IF EXISTS or IF NOT EXISTS clause and database qualifier 
+// is skipped.
+func (this Statement) ObjectName(keyword string) (name string) {
+	tokens := this.SignificantTokens()
+	for index, token := range tokens {
+		if !token.IsKeyword(keyword) {
+			continue
+		}
+		index++
+		for index < len(tokens) && (tokens[index].IsKeyword("IF") || tokens[index].IsKeyword("NOT") || tokens[index].IsKeyword("EXISTS")) {
+			index++
+		}
+		for index < len(tokens) && isName(tokens[index]) {
+			name = unquote(tokens[index].Text)
+			if index + 1 < len(tokens) && tokens[index + 1].Text == "." {
+				index += 2
+				continue
+			}
+			break
+		}
+		return
+	}
+	return
+}
+
+// Return the names of any columns dropped by an ALTER TABLE statement.
+func (this Statement) DroppedColumns() ([]string) {
+	columns := make([]string, 0)
+	tokens  := this.SignificantTokens()
+	for index, token := range tokens {
+		if !token.IsKeyword("DROP") || index + 1 >= len(tokens) {
+			continue
+		}
+		next := tokens[index + 1]
+		if next.IsKeyword("COLUMN") && index + 2 < len(tokens) {
+			columns = append(columns, unquote(tokens[index + 2].Text))
+		} else if isName(next) && !(next.Type == TOKEN_WORD && containsWord(nonColumnDrops, strings.ToUpper(next.Text))) {
+			columns = append(columns, unquote(next.Text))
+		}
+	}
+	return columns
+}
+
+// Return the tokens of the statement excluding whitespace and comments.
+func (this Statement) SignificantTokens() ([]Token) {
+	tokens := make([]Token, 0)
+	for _, token := range this.Tokens {
+		if !token.IsTrivia() {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Check if a token can be the name of an object.
+func isName(token Token) (bool) {
+	return token.Type == TOKEN_WORD || token.Type == TOKEN_IDENTIFIER
+}
+
+// Remove the quotes from a quoted identifier.
+func unquote(name string) (string) {
+	if len(name) > 1 && name[0] == '`' {
+		return strings.Replace(name[1:len(name) - 1], "``", "`", -1)
+	}
+	return name
+}
+
+// Check if a word is in the passed list.
+func containsWord(list []string, word string) (bool) {
+	for _, entry := range list {
+		if entry == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the names of the tables named by a DROP TABLE statement.
+func (this Statement) DroppedTables() ([]string) {
+	tables := make([]string, 0)
+	if !this.StartsWith("DROP", "TABLE") && !this.StartsWith("DROP", "TEMPORARY", "TABLE") {
+		return tables
+	}
+	tokens := this.SignificantTokens()
+	index  := 0
+	for index < len(tokens) && !tokens[index].IsKeyword("TABLE") {
+		index++
+	}
+	index++
+	for index < len(tokens) && (tokens[index].IsKeyword("IF") || tokens[index].IsKeyword("EXISTS")) {
+		index++
+	}
+	for index < len(tokens) && isName(tokens[index]) {
+		name := unquote(tokens[index].Text)
+		if index + 2 < len(tokens) && tokens[index + 1].Text == "." {
+			index += 2
+			name   = unquote(tokens[index].Text)
+		}
+		tables = append(tables, name)
+		if index + 1 >= len(tokens) || tokens[index + 1].Text != "," {
+			break
+		}
+		index += 2
+	}
+	return tables
+}
+
+// Return the names of any tables whose data could be lost by executing the 
+// passed SQL. These are tables which are dropped or truncated and tables which 
+// have columns dropped or redefined, as a redefined column may be narrowed.
+func DestructiveTables(sql string) ([]string) {
+	tables := make([]string, 0)
+	found  := make(map[string]bool)
+	add    := func(table string) {
+		if table != "" && !found[table] {
+			found[table] = true
+			tables       = append(tables, table)
+		}
+	}
+	for _, statement := range SplitStatements(sql) {
+		if statement.StartsWith("DROP") {
+			for _, table := range statement.DroppedTables() {
+				add(table)
+			}
+		} else if statement.StartsWith("TRUNCATE", "TABLE") {
+			add(statement.ObjectName("TABLE"))
+		} else if statement.StartsWith("TRUNCATE") {
+			add(statement.ObjectName("TRUNCATE"))
+		} else if statement.StartsWith("ALTER", "TABLE") || statement.StartsWith("ALTER", "IGNORE", "TABLE") {
+			if len(statement.DroppedColumns()) > 0 || statement.ContainsWords("MODIFY") || statement.ContainsWords("CHANGE") {
+				add(statement.ObjectName("TABLE"))
+			}
+		}
+	}
+	return tables
+}
+
+// Statements which change the schema.
+var schemaOperations = []string{"CREATE", "ALTER", "DROP", "RENAME", "TRUNCATE"}
+
+// Types of object a schema statement can change.
+var objectTypes = []string{"DATABASE", "SCHEMA", "TABLE", "VIEW", "FUNCTION", "PROCEDURE", "TRIGGER", "EVENT", "INDEX"}
+
+// A change made to an object by a statement.
+type ObjectChange struct {
+	Operation string
+	Type string
+	Name string
+}
+
+// Return the object changed by the statement. The second return value is 
+// false if the statement doesn't change an object it can name, e.g. a SET 
+// statement. Statements modifying data are reported as changing the table 
+// they modify.
+func (this Statement) ObjectChange() (change ObjectChange, ok bool) {
+	words := this.Words()
+	if len(words) == 0 {
+		return
+	}
+	operation := words[0]
+	switch {
+		case containsWord(schemaOperations, operation):
+			objectType := "TABLE"
+			for _, word := range words[1:] {
+				if containsWord(objectTypes, word) {
+					objectType = word
+					break
+				}
+			}
+			if operation == "TRUNCATE" && !this.StartsWith("TRUNCATE", "TABLE") {
+				change = ObjectChange{operation, objectType, this.ObjectName("TRUNCATE")}
+			} else {
+				change = ObjectChange{operation, objectType, this.ObjectName(objectType)}
+			}
+
+		case operation == "INSERT" || operation == "REPLACE":
+			change = ObjectChange{operation, "TABLE", this.ObjectName("INTO")}
+
+		case operation == "UPDATE":
+			change = ObjectChange{operation, "TABLE", this.ObjectName("UPDATE")}
+
+		case operation == "DELETE":
+			change = ObjectChange{operation, "TABLE", this.ObjectName("FROM")}
+	}
+	ok = change.Name != ""
+	return
+}
+
+// Return the objects changed by the passed SQL in the order they're first 
+// changed. Each change is only listed once. A statement creating or dropping 
+// an index also alters the table it belongs to, so both are listed.
+func ObjectChanges(sql string) ([]ObjectChange) {
+	changes := make([]ObjectChange, 0)
+	found   := make(map[ObjectChange]bool)
+	add     := func(change ObjectChange) {
+		if !found[change] {
+			found[change] = true
+			changes       = append(changes, change)
+		}
+	}
+	for _, statement := range SplitStatements(sql) {
+		change, ok := statement.ObjectChange()
+		if !ok {
+			continue
+		}
+		add(change)
+		if table := statement.ObjectName("ON"); change.Type == "INDEX" && table != "" {
+			add(ObjectChange{"ALTER", "TABLE", table})
+		}
+	}
+	return changes
+}
+
+// Return the lines of the CREATE TABLE statement of a table in the passed SQL. 
+// Each line is trimmed of indentation and any trailing comma so lines can be 
+// compared between revisions, and auto increment counters are removed. The 
+// second return value is false if the table isn't created by the SQL.
+func TableDefinition(sql string, table string) (lines []string, found bool) {
+	for _, statement := range SplitStatements(sql) {
+		if !statement.StartsWith("CREATE") || statement.ObjectName("TABLE") != table {
+			continue
+		}
+		if change, ok := statement.ObjectChange(); !ok || change.Type != "TABLE" {
+			continue
+		}
+		lines = make([]string, 0)
+		for _, line := range strings.Split(removeAutoIncrementCounters(statement.Sql()), "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines, true
+	}
+	return
+}
@@ -0,0 +1,19 @@
+package sanitise
+
+// Imports.
+import "reflect"
+import "testing"
+
+// Test index statements are reported against the table they belong to as well 
+// as the index.
+func TestObjectChangesOfIndexes(t *testing.T) {
+	changes  := ObjectChanges("CREATE INDEX i ON t (id);\nDROP INDEX j ON `db`.`t`;")
+	expected := []ObjectChange{
+		{"CREATE", "INDEX", "i"},
+		{"ALTER", "TABLE", "t"},
+		{"DROP", "INDEX", "j"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+}
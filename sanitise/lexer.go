@@ -0,0 +1,408 @@
+package sanitise
+
+// Imports.
+import "strings"
+
+// Types of object whose body can be a BEGIN...END block.
+var storedProgramTypes = []string{"FUNCTION", "PROCEDURE", "TRIGGER", "EVENT"}
+
+// Token types produced by the lexer.
+const (
+	TOKEN_WHITESPACE = iota
+	TOKEN_COMMENT
+	TOKEN_WORD
+	TOKEN_STRING
+	TOKEN_IDENTIFIER
+	TOKEN_SYMBOL
+	TOKEN_DELIMITER
+	TOKEN_DELIMITER_COMMAND
+)
+
+// A single lexical token of an SQL script.
+type Token struct {
+	Type int
+	Text string
+	Line int
+}
+
+// Check if the token has no meaning to the server, i.e. whitespace or a 
+// comment.
+func (this Token) IsTrivia() (bool) {
+	return this.Type == TOKEN_WHITESPACE || this.Type == TOKEN_COMMENT
+}
+
+// Check if the token is the passed keyword. The comparison is case insensitive.
+func (this Token) IsKeyword(keyword string) (bool) {
+	return this.Type == TOKEN_WORD && strings.EqualFold(this.Text, keyword)
+}
+
+// A statement of an SQL script. The tokens include any whitespace and comments 
+// preceding the statement and the delimiter that terminates it, so joining the 
+// tokens of all statements reproduces the original script exactly.
+type Statement struct {
+	Tokens []Token
+}
+
+// Return the line the statement starts on, ignoring any preceding whitespace 
+// and comments.
+func (this Statement) Line() (int) {
+	for _, token := range this.Tokens {
+		if !token.IsTrivia() {
+			return token.Line
+		}
+	}
+	if len(this.Tokens) > 0 {
+		return this.Tokens[len(this.Tokens) - 1].Line
+	}
+	return 0
+}
+
+// Return the whitespace and comments preceding the statement.
+func (this Statement) LeadingTrivia() (string) {
+	output := make([]string, 0)
+	for _, token := range this.Tokens {
+		if !token.IsTrivia() {
+			break
+		}
+		output = append(output, token.Text)
+	}
+	return strings.Join(output, "")
+}
+
+// Return the SQL of the statement without any preceding whitespace and 
+// comments or the terminating delimiter.
+func (this Statement) Sql() (string) {
+	output  := make([]string, 0)
+	started := false
+	for _, token := range this.Tokens {
+		if token.Type == TOKEN_DELIMITER {
+			break
+		}
+		if started || !token.IsTrivia() {
+			started = true
+			output = append(output, token.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(output, ""))
+}
+
+// Return all keywords and unquoted names of the statement in upper case.
+func (this Statement) Words() ([]string) {
+	words := make([]string, 0)
+	for _, token := range this.Tokens {
+		if token.Type == TOKEN_WORD {
+			words = append(words, strings.ToUpper(token.Text))
+		}
+	}
+	return words
+}
+
+// Check if the statement starts with the passed keywords.
+func (this Statement) StartsWith(keywords ...string) (bool) {
+	words := this.Words()
+	if len(words) < len(keywords) {
+		return false
+	}
+	for index, keyword := range keywords {
+		if words[index] != strings.ToUpper(keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if the statement contains no SQL.
+func (this Statement) IsEmpty() (bool) {
+	return this.Sql() == ""
+}
+
+// Check if the statement is a client DELIMITER command.
+func (this Statement) IsDelimiterCommand() (bool) {
+	for _, token := range this.Tokens {
+		if token.Type == TOKEN_DELIMITER_COMMAND {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the statement creates a database.
+func (this Statement) IsCreateDatabase() (bool) {
+	return this.StartsWith("CREATE", "DATABASE") || this.StartsWith("CREATE", "SCHEMA")
+}
+
+// Check if the statement changes the default database.
+func (this Statement) IsUse() (bool) {
+	return this.StartsWith("USE")
+}
+
+// Split an SQL script into statements. The script is lexed the way the MySql 
+// command line client reads it. Quoted strings, quoted identifiers and comments 
+// are never split or searched for delimiters. Executable comments, e.g. 
+// '/*!40101 SET NAMES utf8 */', are run by the server so their contents are 
+// lexed as SQL. DELIMITER commands change the delimiter used to terminate the 
+// following statements. When the default delimiter is used, semi-colons inside 
+// the BEGIN...END body of a stored program don't terminate the statement.
+func SplitStatements(sql string) ([]Statement) {
+	lexer := &lexer{sql: sql, line: 1, delimiter: ";"}
+	lexer.run()
+	return lexer.statements
+}
+
+// The lexer state.
+type lexer struct {
+	sql string
+	position int
+	line int
+	delimiter string
+	statements []Statement
+	tokens []Token
+	depth int
+	pendingEnd bool
+	executable bool
+}
+
+// Lex the entire script.
+func (this *lexer) run() {
+	for this.position < len(this.sql) {
+		this.next()
+	}
+	if len(this.tokens) > 0 {
+		this.endStatement()
+	}
+}
+
+// Lex the next token.
+func (this *lexer) next() {
+	rest := this.sql[this.position:]
+	char := rest[0]
+
+	switch {
+		case isWhitespace(char):
+			this.emit(TOKEN_WHITESPACE, this.scanWhile(isWhitespace))
+
+		case strings.HasPrefix(rest, "/*!"):
+			// The comment can start with the minimum server version to run it.
+			length := 3
+			for length < len(rest) && rest[length] >= '0' && rest[length] <= '9' {
+				length++
+			}
+			this.executable = true
+			this.emit(TOKEN_SYMBOL, length)
+
+		case this.executable && strings.HasPrefix(rest, "*/"):
+			this.executable = false
+			this.emit(TOKEN_SYMBOL, 2)
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				this.emit(TOKEN_COMMENT, len(rest))
+			} else {
+				this.emit(TOKEN_COMMENT, end + 4)
+			}
+
+		case char == '#' || (strings.HasPrefix(rest, "--") && (len(rest) == 2 || isWhitespace(rest[2]))):
+			this.emit(TOKEN_COMMENT, lineLength(rest))
+
+		case this.atStatementStart() && isDelimiterCommand(rest):
+			length := lineLength(rest)
+			this.delimiter = parseDelimiterCommand(rest[:length])
+			this.emit(TOKEN_DELIMITER_COMMAND, length)
+			this.endStatement()
+
+		case this.delimiter != ";" && strings.HasPrefix(rest, this.delimiter):
+			this.emit(TOKEN_DELIMITER, len(this.delimiter))
+			this.endStatement()
+
+		case char == ';' && this.delimiter == ";":
+			this.resolveEnd("")
+			if this.depth > 0 {
+				this.emit(TOKEN_SYMBOL, 1)
+			} else {
+				this.emit(TOKEN_DELIMITER, 1)
+				this.endStatement()
+			}
+
+		case char == '\'' || char == '"':
+			this.emit(TOKEN_STRING, quotedLength(rest, char))
+
+		case char == '`':
+			this.emit(TOKEN_IDENTIFIER, quotedLength(rest, char))
+
+		case isWordCharacter(char):
+			this.emit(TOKEN_WORD, this.scanWord())
+			this.trackBlocks(this.tokens[len(this.tokens) - 1].Text)
+
+		default:
+			this.emit(TOKEN_SYMBOL, 1)
+	}
+}
+
+// Emit a token of the passed length from the current position.
+func (this *lexer) emit(tokenType int, length int) {
+	text := this.sql[this.position:this.position + length]
+	this.tokens = append(this.tokens, Token{tokenType, text, this.line})
+	this.position += length
+	this.line += strings.Count(text, "\n")
+}
+
+// Finish the current statement.
+func (this *lexer) endStatement() {
+	this.statements = append(this.statements, Statement{this.tokens})
+	this.tokens     = make([]Token, 0)
+	this.depth      = 0
+	this.pendingEnd = false
+}
+
+// Check if only whitespace and comments have been lexed since the last 
+// statement finished.
+func (this *lexer) atStatementStart() (bool) {
+	for _, token := range this.tokens {
+		if !token.IsTrivia() {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the length of the run of characters from the current position that 
+// satisfy the passed predicate.
+func (this *lexer) scanWhile(predicate func(byte) bool) (int) {
+	length := 0
+	for this.position + length < len(this.sql) && predicate(this.sql[this.position + length]) {
+		length++
+	}
+	return length
+}
+
+// Return the length of the word at the current position. The word finishes 
+// early if a custom delimiter follows it directly, e.g. 'END$$'.
+func (this *lexer) scanWord() (int) {
+	length := 0
+	for this.position + length < len(this.sql) && isWordCharacter(this.sql[this.position + length]) {
+		if length > 0 && this.delimiter != ";" && strings.HasPrefix(this.sql[this.position + length:], this.delimiter) {
+			break
+		}
+		length++
+	}
+	return length
+}
+
+// Track the nesting depth of compound statement blocks within stored programs. 
+// BEGIN is only counted in the body of a stored program, elsewhere it can be a 
+// name, e.g. of a column. CASE is counted because it is closed by an END too. Any END that closes an IF, 
+// LOOP, WHILE or REPEAT is ignored which can only be determined by the word 
+// that follows it.
+func (this *lexer) trackBlocks(word string) {
+	if this.resolveEnd(word) {
+		return
+	}
+	switch strings.ToUpper(word) {
+		case "BEGIN":
+			if this.inStoredProgram() {
+				this.depth++
+			}
+		case "CASE":
+			this.depth++
+		case "END":
+			this.pendingEnd = true
+	}
+}
+
+// Check if the statement lexed so far creates or alters a stored program. The 
+// type of object is the first object type named after CREATE or ALTER, as for 
+// the object changed by a statement.
+func (this *lexer) inStoredProgram() (bool) {
+	words := Statement{this.tokens}.Words()
+	if words[0] != "CREATE" && words[0] != "ALTER" {
+		return false
+	}
+	for _, word := range words[1:] {
+		if containsWord(objectTypes, word) {
+			return containsWord(storedProgramTypes, word)
+		}
+	}
+	return false
+}
+
+// Resolve a preceding END now the word following it is known. Returns true if 
+// the word is part of the END, e.g. the CASE of END CASE, so it doesn't open a 
+// block itself.
+func (this *lexer) resolveEnd(next string) (consumed bool) {
+	if !this.pendingEnd {
+		return false
+	}
+	this.pendingEnd = false
+	switch strings.ToUpper(next) {
+		case "IF", "LOOP", "WHILE", "REPEAT":
+			return true
+		case "CASE":
+			consumed = true
+	}
+	if this.depth > 0 {
+		this.depth--
+	}
+	return
+}
+
+// Check if the passed character is whitespace.
+func isWhitespace(char byte) (bool) {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
+
+// Check if the passed character can be part of an unquoted word.
+func isWordCharacter(char byte) (bool) {
+	return (char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9') ||
+		char == '_' || char == '$' || char >= 0x80
+}
+
+// Return the length of the passed text up to but not including the end of the 
+// line.
+func lineLength(text string) (int) {
+	end := strings.Index(text, "\n")
+	if end < 0 {
+		return len(text)
+	}
+	return end
+}
+
+// Return the length of the quoted text at the start of the passed text. 
+// Doubled quotes are treated as escaped quotes. Backslash escapes are also 
+// recognised in strings. If the quote is never closed the rest of the text is 
+// returned.
+func quotedLength(text string, quote byte) (int) {
+	for index := 1; index < len(text); index++ {
+		if text[index] == '\\' && quote != '`' {
+			index++
+		} else if text[index] == quote {
+			if index + 1 < len(text) && text[index + 1] == quote {
+				index++
+			} else {
+				return index + 1
+			}
+		}
+	}
+	return len(text)
+}
+
+// Check if the passed text starts with a DELIMITER command.
+func isDelimiterCommand(text string) (bool) {
+	return len(text) > 10 && strings.EqualFold(text[:9], "DELIMITER") && (text[9] == ' ' || text[9] == '\t')
+}
+
+// Parse the new delimiter from a DELIMITER command. The delimiter can be naked 
+// or enclosed in quotes.
+func parseDelimiterCommand(command string) (string) {
+	fields := strings.Fields(command[9:])
+	if len(fields) == 0 {
+		return ";"
+	}
+	delimiter := fields[0]
+	if len(delimiter) > 2 && strings.ContainsAny(delimiter[:1], "`'\"") && delimiter[len(delimiter) - 1] == delimiter[0] {
+		delimiter = delimiter[1:len(delimiter) - 1]
+	}
+	return delimiter
+}
@@ -0,0 +1,100 @@
+package sanitise
+
+// Imports.
+import "flag"
+import "io/ioutil"
+import "path/filepath"
+import "strings"
+import "testing"
+
+// Golden files are rewritten from the lexer's output when set.
+var update = flag.Bool("update", false, "Rewrite the golden files of the lexer tests.")
+
+// Separates the statements of a golden file.
+const GOLDEN_SEPARATOR string = "\n----\n"
+
+// The scripts of the golden corpus. Each script has a golden file of the same 
+// name holding the SQL of the statements it should be split into.
+func goldenScripts(t *testing.T) ([]string) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", "split", "*.sql"))
+	if err != nil || len(scripts) == 0 {
+		t.Fatalf("expected the golden corpus in testdata/split")
+	}
+	return scripts
+}
+
+// Read a file of the golden corpus.
+func readGoldenFile(t *testing.T, file string) (string) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("can not read '%s': %s", file, err)
+	}
+	return string(contents)
+}
+
+// Return the SQL of the statements that aren't empty or DELIMITER commands.
+func statementSql(statements []Statement) ([]string) {
+	sql := make([]string, 0)
+	for _, statement := range statements {
+		if statement.IsEmpty() || statement.IsDelimiterCommand() {
+			continue
+		}
+		sql = append(sql, statement.Sql())
+	}
+	return sql
+}
+
+// Test scripts are split into the statements of their golden files.
+func TestSplitStatements(t *testing.T) {
+	for _, script := range goldenScripts(t) {
+		golden := strings.TrimSuffix(script, ".sql") + ".expected"
+		actual := strings.Join(statementSql(SplitStatements(readGoldenFile(t, script))), GOLDEN_SEPARATOR) + "\n"
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+				t.Fatalf("can not write '%s': %s", golden, err)
+			}
+			continue
+		}
+		if expected := readGoldenFile(t, golden); actual != expected {
+			t.Errorf("%s: expected %q, got %q", script, expected, actual)
+		}
+	}
+}
+
+// Test joining the tokens of all statements reproduces the original script.
+func TestSplitStatementsIsLossless(t *testing.T) {
+	for _, script := range goldenScripts(t) {
+		sql    := readGoldenFile(t, script)
+		output := make([]string, 0)
+		for _, statement := range SplitStatements(sql) {
+			for _, token := range statement.Tokens {
+				output = append(output, token.Text)
+			}
+		}
+		if actual := strings.Join(output, ""); actual != sql {
+			t.Errorf("%s: expected %q, got %q", script, sql, actual)
+		}
+	}
+}
+
+// Test USE statements are only recognised outside string literals.
+func TestIsUse(t *testing.T) {
+	statements := SplitStatements("INSERT INTO t VALUES ('USE other;');\nUSE db;")
+	if statements[0].IsUse() {
+		t.Errorf("expected %q not to be a USE statement", statements[0].Sql())
+	}
+	if !statements[1].IsUse() {
+		t.Errorf("expected %q to be a USE statement", statements[1].Sql())
+	}
+}
+
+// Test statements report the line they start on.
+func TestStatementLine(t *testing.T) {
+	statements := SplitStatements("-- comment\nSELECT 1;\n\nSELECT\n2;")
+	if line := statements[0].Line(); line != 2 {
+		t.Errorf("expected the first statement to start on line 2, got %d", line)
+	}
+	if line := statements[1].Line(); line != 4 {
+		t.Errorf("expected the second statement to start on line 4, got %d", line)
+	}
+}
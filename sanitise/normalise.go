@@ -0,0 +1,81 @@
+package sanitise
+
+// Imports.
+import "github.com/nomad-software/snap/config"
+import "regexp"
+import "strings"
+
+// This function normalises a generated schema so that it only changes when the 
+// schema itself changes. Output from the MySql server contains details such as 
+// auto increment counters and definers which change over time or between 
+// servers without any change to the schema. Comparing such output would report 
+// differences where none exist.
+//
+// The passed rules specify which of the following normalisations are applied:
+//
+// 1. Remove AUTO_INCREMENT counters from table options.
+// 2. Remove DEFINER clauses.
+// 3. Remove trailing whitespace and collapse multiple blank lines.
+// 4. Remove partitioning clauses.
+// 5. Remove object comments.
+func NormaliseSql(sql string, rules map[string]bool) (string) {
+	sql = ConvertToUnixLineEndings(sql)
+	if rules[config.NORMALISE_AUTO_INCREMENT] {
+		sql = removeAutoIncrementCounters(sql)
+	}
+	if rules[config.NORMALISE_DEFINERS] {
+		sql = removeDefiners(sql)
+	}
+	if rules[config.NORMALISE_PARTITIONS] {
+		sql = removePartitions(sql)
+	}
+	if rules[config.NORMALISE_COMMENTS] {
+		sql = removeComments(sql)
+	}
+	if rules[config.NORMALISE_WHITESPACE] {
+		sql = canonicaliseWhitespace(sql)
+	}
+	return sql
+}
+
+// Remove the AUTO_INCREMENT counter from table options. The AUTO_INCREMENT 
+// column attribute is left untouched as it has no value assigned.
+func removeAutoIncrementCounters(sql string) (string) {
+	pattern := regexp.MustCompile("(?i)[ \t]+AUTO_INCREMENT=\\d+")
+	return pattern.ReplaceAllString(sql, "")
+}
+
+// Remove any DEFINER clauses in the passed SQL. The user and host can be 
+// quoted in any of the ways MySql allows.
+func removeDefiners(sql string) (string) {
+	pattern := regexp.MustCompile("(?i)[ \t]+DEFINER\\s*=\\s*(?:`[^`]*`|'[^']*'|[^\\s@]+)@(?:`[^`]*`|'[^']*'|\\S+)")
+	return pattern.ReplaceAllString(sql, "")
+}
+
+// Remove any partitioning clauses in the passed SQL. MySql always outputs these 
+// within a version specific comment.
+func removePartitions(sql string) (string) {
+	pattern := regexp.MustCompile("(?is)\\s*/\\*!\\d{5} PARTITION BY .*?\\*/")
+	return pattern.ReplaceAllString(sql, "")
+}
+
+// Remove any comments attached to tables, columns, indexes and routines.
+func removeComments(sql string) (string) {
+	pattern := regexp.MustCompile("(?i)[ \t]+COMMENT(?:\\s*=\\s*|\\s+)'(?:[^'\\\\]|\\\\.|'')*'")
+	return pattern.ReplaceAllString(sql, "")
+}
+
+// Remove trailing whitespace from all lines, collapse multiple blank lines 
+// into one and remove any leading or trailing blank lines.
+func canonicaliseWhitespace(sql string) (string) {
+	lines  := strings.Split(sql, "\n")
+	output := make([]string, 0)
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(output) == 0 || output[len(output)-1] == "") {
+			continue
+		}
+		output = append(output, line)
+	}
+	return strings.TrimRight(strings.Join(output, "\n"), "\n")
+}
@@ -1,7 +1,6 @@
 package sanitise
 
 // Imports.
-import "regexp"
 import "strings"
 
 // This function sanitises SQL for use by this program. The reason for this is 
@@ -12,71 +11,38 @@ import "strings"
 // executed by this program.
 //
 // All carriage returns are removed from the passed SQL string to help parsing. 
-// All SQL lines containing the following statements are removed:
+// The SQL is split into statements and the following statements are removed:
 //
 // 1. CREATE DATABASE
 // 2. CREATE SCHEMA
 // 3. USE
 // 4. DELIMITER
+//
+// Any custom delimiters are replaced by the default semi-colon. Comments are 
+// kept in place, along with any comments preceding a removed statement.
 func SanitiseSql(sql string) (string) {
-	sql = ConvertToUnixLineEndings(sql)
-	sql = removeCreateDatabaseStatements(sql)
-	sql = removeUseStatements(sql)
-	sql = reverseDelimiterChanges(sql)
-	return sql
-}
-
-// Remove any CREATE DATABASE or CREATE SCHEMA statements in the passed SQL.
-func removeCreateDatabaseStatements(sql string) (string) {
-	pattern := regexp.MustCompile("(?i)(CREATE DATABASE\\s+|CREATE SCHEMA\\s+)")
-	lines   := strings.Split(sql, "\n")
-	output  := make([]string, 0)
-	for _, line := range lines {
-		if !pattern.MatchString(line) {
-			output = append(output, line)
-		}
-	}
-	return strings.Join(output, "\n")
-}
-
-// Remove any USE statements in the passed SQL.
-func removeUseStatements(sql string) (string) {
-	pattern := regexp.MustCompile("(?i)USE\\s+")
-	lines   := strings.Split(sql, "\n")
-	output  := make([]string, 0)
-	for _, line := range lines {
-		if !pattern.MatchString(line) {
-			output = append(output, line)
+	sql     = ConvertToUnixLineEndings(sql)
+	output := make([]string, 0)
+	for _, statement := range SplitStatements(sql) {
+		if statement.IsDelimiterCommand() || statement.IsCreateDatabase() || statement.IsUse() {
+			output = append(output, statement.LeadingTrivia())
+		} else {
+			output = append(output, reverseDelimiterChange(statement))
 		}
 	}
-	return strings.Join(output, "\n")
+	return strings.Join(output, "")
 }
 
-// Reverse any delimiter changes in the passed SQL. This removes all lines 
-// containing a DELIMITER statement and substitutes all occurances of custom 
-// delimiters for the default semi-colon.
-func reverseDelimiterChanges(sql string) (string) {
-	// Match naked or quote delimited delimiters.
-	pattern := regexp.MustCompile("(?i)DELIMITER\\s+(?:[`'\"](.*)[`'\"]|(\\S+))")
-	lines   := strings.Split(sql, "\n")
-	output  := make([]string, 0)
-	var delimiter string;
-	for _, line := range lines {
-		if pattern.MatchString(line) {
-			matches := pattern.FindStringSubmatch(line)
-			if matches[1] != "" {
-				// This capture group contains matched quoted delimiters.
-				delimiter = matches[1]
-			} else {
-				// This capture group contains matched naked delimiters.
-				delimiter = matches[2]
-			}
+// Return the SQL of a statement terminated with the default semi-colon, 
+// whatever delimiter was used to terminate it.
+func reverseDelimiterChange(statement Statement) (string) {
+	output := make([]string, 0)
+	for _, token := range statement.Tokens {
+		if token.Type == TOKEN_DELIMITER {
+			output = append(output, ";")
 		} else {
-			if delimiter != "" {
-				line = strings.Replace(line, delimiter, ";", -1)
-			}
-			output = append(output, line)
+			output = append(output, token.Text)
 		}
 	}
-	return strings.Join(output, "\n")
+	return strings.Join(output, "")
 }
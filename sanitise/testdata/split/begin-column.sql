@@ -0,0 +1,3 @@
+CREATE TABLE t (id INT, begin DATE);
+ALTER TABLE t ADD COLUMN `begin` DATE, ADD begin_at DATE, ADD end DATE;
+CREATE TABLE u (id INT);
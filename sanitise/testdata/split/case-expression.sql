@@ -0,0 +1,2 @@
+CREATE FUNCTION f(x INT) RETURNS INT BEGIN RETURN CASE x WHEN 1 THEN 2 ELSE 3 END; END;
+SELECT 1;
@@ -0,0 +1,3 @@
+-- first; comment
+SELECT 1; # second; comment
+SELECT /* third; comment */ 2;
@@ -0,0 +1,2 @@
+CREATE DEFINER=root@localhost TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN SET NEW.x = 1; END;
+CREATE TABLE u (id INT);
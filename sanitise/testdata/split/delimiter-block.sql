@@ -0,0 +1,4 @@
+DELIMITER $$
+CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END$$
+DELIMITER ;
+CREATE TABLE t (id INT);
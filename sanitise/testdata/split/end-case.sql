@@ -0,0 +1,2 @@
+CREATE PROCEDURE p() BEGIN CASE x WHEN 1 THEN SELECT 1; END CASE; END;
+CREATE TABLE t (id INT);
@@ -0,0 +1,2 @@
+CREATE PROCEDURE p() BEGIN IF x THEN SELECT 1; END IF; END;
+CREATE TABLE t (id INT);
@@ -0,0 +1,2 @@
+CREATE PROCEDURE p() BEGIN l: LOOP LEAVE l; END LOOP; WHILE x DO SELECT 1; END WHILE; END;
+SELECT 1;
@@ -0,0 +1,2 @@
+INSERT INTO t VALUES ('it''s; fine', 'back\'slash; too');
+SELECT 1;
@@ -0,0 +1,2 @@
+CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO BEGIN DELETE FROM t; END;
+SELECT 1;
@@ -0,0 +1,4 @@
+/*!40101 SET NAMES utf8 */;
+/*!40014 SET FOREIGN_KEY_CHECKS=0 */;
+/* not; executed */
+SELECT 1;
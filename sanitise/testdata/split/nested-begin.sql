@@ -0,0 +1,2 @@
+CREATE PROCEDURE p() BEGIN BEGIN SELECT 1; END; SELECT 2; END;
+SELECT 3;
@@ -0,0 +1,2 @@
+INSERT INTO t VALUES ('USE other;');
+USE db;
@@ -16,8 +16,14 @@ CREATE TABLE IF NOT EXISTS `snap_config`.`initialisedDatabases` (
   `name` VARCHAR(64) NOT NULL,
   `dateInitialised` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `currentSchemaRevision` INT UNSIGNED NOT NULL,
+  `historyDatabaseId` INT UNSIGNED NULL DEFAULT NULL COMMENT 'The database whose revisions are shared by members of a group.',
   PRIMARY KEY (`id`),
-  UNIQUE INDEX `uniqueDatabaseName` (`name` ASC))
+  UNIQUE INDEX `uniqueDatabaseName` (`name` ASC),
+  CONSTRAINT `fk_initialisedDatabases_historyDatabase`
+    FOREIGN KEY (`historyDatabaseId`)
+    REFERENCES `snap_config`.`initialisedDatabases` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 
@@ -30,9 +36,11 @@ CREATE TABLE IF NOT EXISTS `snap_config`.`revisions` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
   `databaseId` INT UNSIGNED NOT NULL,
   `revision` INT UNSIGNED NOT NULL,
-  `upSql` TEXT NULL DEFAULT NULL,
-  `downSql` TEXT NULL DEFAULT NULL,
-  `fullSql` TEXT NOT NULL COMMENT 'SQL snapshot after applying update SQL.',
+  `upSql` LONGTEXT NULL DEFAULT NULL,
+  `downSql` LONGTEXT NULL DEFAULT NULL,
+  `fullSql` LONGBLOB NOT NULL COMMENT 'SQL snapshot after applying update SQL.',
+  `fullSqlEncoding` ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text' COMMENT 'How the SQL snapshot is stored.',
+  `status` ENUM('pending', 'complete') NOT NULL DEFAULT 'complete' COMMENT 'Pending until the update SQL has been applied.',
   `comment` VARCHAR(255) NOT NULL,
   `author` VARCHAR(255) NOT NULL,
   `dateApplied` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -47,6 +55,140 @@ CREATE TABLE IF NOT EXISTS `snap_config`.`revisions` (
 ENGINE = InnoDB;
 
 
+-- -----------------------------------------------------
+-- Table `snap_config`.`objectFilters`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`objectFilters` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`objectFilters` (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `databaseName` VARCHAR(64) NOT NULL,
+  `filterType` ENUM('include', 'exclude') NOT NULL,
+  `pattern` VARCHAR(255) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uniqueDatabaseNameAndPattern` (`databaseName` ASC, `filterType` ASC, `pattern` ASC))
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`updateProgress`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`updateProgress` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`updateProgress` (
+  `databaseId` INT UNSIGNED NOT NULL,
+  `targetRevision` INT UNSIGNED NOT NULL,
+  `revision` INT UNSIGNED NOT NULL,
+  `direction` ENUM('up', 'down') NOT NULL,
+  `statementIndex` INT UNSIGNED NOT NULL,
+  `error` TEXT NULL DEFAULT NULL,
+  `dateUpdated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`databaseId`),
+  CONSTRAINT `fk_updateProgress_initialisedDatabases`
+    FOREIGN KEY (`databaseId`)
+    REFERENCES `snap_config`.`initialisedDatabases` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`backups`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`backups` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`backups` (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `databaseId` INT UNSIGNED NOT NULL,
+  `revision` INT UNSIGNED NOT NULL,
+  `direction` ENUM('up', 'down') NOT NULL,
+  `backupDatabase` VARCHAR(64) NOT NULL,
+  `tableNames` TEXT NOT NULL COMMENT 'Comma separated names of the tables backed up.',
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uniqueBackupDatabase` (`backupDatabase` ASC),
+  CONSTRAINT `fk_backups_initialisedDatabases`
+    FOREIGN KEY (`databaseId`)
+    REFERENCES `snap_config`.`initialisedDatabases` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`tempDatabases`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`tempDatabases` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`tempDatabases` (
+  `name` VARCHAR(64) NOT NULL,
+  `owner` VARCHAR(255) NOT NULL,
+  `host` VARCHAR(255) NOT NULL,
+  `processId` INT UNSIGNED NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`name`))
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`databaseGroups`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`databaseGroups` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`databaseGroups` (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(64) NOT NULL,
+  `historyDatabaseId` INT UNSIGNED NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uniqueGroupName` (`name` ASC),
+  UNIQUE INDEX `uniqueHistoryDatabaseId` (`historyDatabaseId` ASC),
+  CONSTRAINT `fk_databaseGroups_initialisedDatabases`
+    FOREIGN KEY (`historyDatabaseId`)
+    REFERENCES `snap_config`.`initialisedDatabases` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`archivedRevisions`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`archivedRevisions` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`archivedRevisions` (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `databaseName` VARCHAR(64) NOT NULL,
+  `reason` ENUM('uninit', 'rebaseline') NOT NULL,
+  `dateArchived` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `revision` INT UNSIGNED NOT NULL,
+  `upSql` LONGTEXT NULL DEFAULT NULL,
+  `downSql` LONGTEXT NULL DEFAULT NULL,
+  `fullSql` LONGBLOB NOT NULL,
+  `fullSqlEncoding` ENUM('text', 'compressed', 'delta') NOT NULL DEFAULT 'text',
+  `comment` VARCHAR(255) NOT NULL,
+  `author` VARCHAR(255) NOT NULL,
+  `dateApplied` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `databaseNameAndDate` (`databaseName` ASC, `dateArchived` ASC))
+ENGINE = InnoDB;
+
+
+-- -----------------------------------------------------
+-- Table `snap_config`.`configVersions`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `snap_config`.`configVersions` ;
+
+CREATE TABLE IF NOT EXISTS `snap_config`.`configVersions` (
+  `version` INT UNSIGNED NOT NULL,
+  `description` VARCHAR(255) NOT NULL,
+  `dateApplied` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`))
+ENGINE = InnoDB;
+
+INSERT INTO `snap_config`.`configVersions` (`version`, `description`) VALUES (13, 'Snap config database created.');
+
+
 SET SQL_MODE=@OLD_SQL_MODE;
 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS;
 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS;
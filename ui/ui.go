@@ -0,0 +1,22 @@
+package ui
+
+// Imports.
+import _ "embed"
+import "net/http"
+
+// The single page web UI. It reads everything it shows from the Json API.
+//go:embed index.html
+var index []byte
+
+// Serve the web UI. Only the page itself is served, views within it are 
+// chosen using the fragment of the URL.
+func Handler() (http.Handler) {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/" && request.URL.Path != "/index.html" {
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Write(index)
+	})
+}